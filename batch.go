@@ -0,0 +1,180 @@
+package core
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+type (
+	// BatchProgress is reported by SearchBatch each time a GTIN×scraper work
+	// item completes
+	BatchProgress struct {
+		Done   int
+		Total  int
+		Query  string
+		Source SourceReport
+	}
+
+	batchItem struct {
+		query string
+		idx   int
+	}
+)
+
+// SearchBatch looks up every query through a single worker pool of
+// MaxConcurrency workers shared by all GTIN×scraper work items, so the global
+// concurrency stays bounded however long the batch is. MaxConcurrencyPerScraper
+// additionally caps the in-flight requests per website.
+//
+// The Engine timeout bounds each work item, while ctx bounds the whole batch.
+// With tiers, each tier runs as a phase over the queries still unanswered.
+// Results are keyed by the queries as given. Queries for the same product, like
+// a GTIN with and without its leading zeros, are looked up once.
+func (e *Engine) SearchBatch(ctx context.Context, queries []string) (map[string]*SearchResult, error) {
+	e.setup()
+
+	if len(e.scrapers) == 0 {
+		return nil, ErrMissingScraper
+	}
+
+	start := time.Now()
+	results := make(map[string]*SearchResult, len(queries))
+	found := make(map[string][][]Product, len(queries)) // Each work item only writes its own index
+	unique := make([]string, 0, len(queries))
+	looked := map[string]string{}     // The query looked up for each canonical query
+	duplicates := map[string]string{} // The query looked up for each duplicate
+	for _, query := range queries {
+		if _, ok := results[query]; ok {
+			continue
+		}
+		key := canonicalQuery(query)
+		if first, ok := looked[key]; ok {
+			duplicates[query] = first
+			continue
+		}
+		looked[key] = query
+		results[query] = &SearchResult{Query: query, Sources: make([]SourceReport, len(e.scrapers))}
+		found[query] = make([][]Product, len(e.scrapers))
+		unique = append(unique, query)
+		e.searchStarted(ctx, SearchStartedEvent{Query: query, Scrapers: len(e.scrapers)})
+	}
+
+	var perScraper []chan struct{}
+	if e.config.MaxConcurrencyPerScraper > 0 {
+		perScraper = make([]chan struct{}, len(e.scrapers))
+		for i := range perScraper {
+			perScraper[i] = make(chan struct{}, e.config.MaxConcurrencyPerScraper)
+		}
+	}
+
+	progress := BatchProgress{Total: len(unique) * len(e.scrapers)}
+	var progressMutex sync.Mutex
+
+	complete := func(item batchItem, report SourceReport, products []Product) {
+		results[item.query].Sources[item.idx] = report
+		found[item.query][item.idx] = products
+
+		progressMutex.Lock()
+		progress.Done++
+		progress.Query, progress.Source = item.query, report
+		if e.config.Progress != nil {
+			e.config.Progress(progress)
+		}
+		progressMutex.Unlock()
+	}
+
+	// Tiers run as phases: a query only reaches the next tier when the
+	// products of the previous ones do not satisfy the stop condition
+	tiers := e.tiers()
+	pending := unique
+	for t, tier := range tiers {
+		items := []batchItem{}
+		next := []string{}
+		for _, query := range pending {
+			if t > 0 && e.stop(slices.Concat(found[query]...)) {
+				for _, later := range tiers[t:] {
+					for _, idx := range later {
+						complete(batchItem{query: query, idx: idx}, SourceReport{Website: e.scrapers[idx].Info(), Status: StatusSkipped, Err: ErrNotNeeded}, nil)
+					}
+				}
+				continue
+			}
+
+			next = append(next, query)
+			for _, idx := range tier {
+				items = append(items, batchItem{query: query, idx: idx})
+			}
+		}
+		pending = next
+
+		e.batchPhase(ctx, items, perScraper, complete)
+	}
+
+	for _, query := range unique {
+		result := results[query]
+		result.Products = []Product{}
+		for _, products := range found[query] {
+			result.Products = append(result.Products, products...)
+		}
+		rankByText(query, result.Products)
+		e.searchFinished(ctx, SearchFinishedEvent{Query: query, Result: result, Duration: time.Since(start), Err: ctx.Err()})
+	}
+
+	for query, first := range duplicates { // Each query gets its own result
+		result := results[first]
+		results[query] = &SearchResult{Query: query, Products: cloneProducts(result.Products), Sources: slices.Clone(result.Sources)}
+	}
+
+	return results, ctx.Err()
+}
+
+// batchPhase runs items through a pool of MaxConcurrency workers. Items are
+// expected query by query, so consecutive items hit different websites.
+func (e *Engine) batchPhase(ctx context.Context, items []batchItem, perScraper []chan struct{}, complete func(batchItem, SourceReport, []Product)) {
+	queue := make(chan batchItem)
+	go func() {
+		defer close(queue)
+		for _, item := range items {
+			queue <- item
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < min(e.config.MaxConcurrency, len(items)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range queue {
+				report, products := e.batchScrape(ctx, perScraper, item)
+				complete(item, report, products)
+			}
+		}()
+	}
+
+	wg.Wait() // Wait for all workers to drain the queue
+}
+
+// batchScrape runs a single work item, waiting for a per-scraper slot first
+func (e *Engine) batchScrape(ctx context.Context, perScraper []chan struct{}, item batchItem) (SourceReport, []Product) {
+	scraper := e.scrapers[item.idx]
+
+	if perScraper != nil {
+		select {
+		case perScraper[item.idx] <- struct{}{}:
+			defer func() { <-perScraper[item.idx] }()
+		case <-ctx.Done():
+			return SourceReport{Website: scraper.Info(), Status: StatusSkipped, Err: ctx.Err()}, nil
+		}
+	}
+
+	if e.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.config.Timeout)
+		defer cancel()
+	}
+
+	report, products := e.scrape(ctx, scraper, item.query)
+	return report, e.filter(ctx, item.query, report.Website, products)
+}
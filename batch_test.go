@@ -0,0 +1,90 @@
+package core_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	core "achapromo.com/productscout"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingScraper struct {
+	url      string
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (c *countingScraper) Scrape(ctx context.Context, query string) ([]core.Product, error) {
+	n := c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	for {
+		peak := c.peak.Load()
+		if n <= peak || c.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	return []core.Product{{Name: "Product " + query, GTIN: query}}, nil
+}
+
+func (c *countingScraper) Info() core.Website {
+	return core.Website{URL: c.url}
+}
+
+func TestEngine_SearchBatch(t *testing.T) {
+	first := &countingScraper{url: "https://first.example"}
+	second := &countingScraper{url: "https://second.example"}
+
+	var mu sync.Mutex
+	var progress []core.BatchProgress
+	engine := core.NewEngine(
+		core.WithMaxConcurrency(4),
+		core.WithMaxConcurrencyPerScraper(1),
+		core.WithProgress(func(p core.BatchProgress) {
+			mu.Lock()
+			progress = append(progress, p)
+			mu.Unlock()
+		}),
+		core.WithScrapers(first, second),
+	)
+
+	gtins := []string{"7898215151784", "7898422745523", "7895800430002", "7898215151784"}
+	results, err := engine.SearchBatch(context.Background(), gtins)
+
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+	for _, gtin := range gtins {
+		if assert.Contains(t, results, gtin) {
+			assert.Len(t, results[gtin].Products, 2)
+			assert.Equal(t, 2, results[gtin].Count(core.StatusOK))
+		}
+	}
+	assert.EqualValues(t, 1, first.peak.Load())
+	assert.EqualValues(t, 1, second.peak.Load())
+	if assert.Len(t, progress, 6) {
+		assert.Equal(t, 6, progress[5].Done)
+		assert.Equal(t, 6, progress[5].Total)
+	}
+}
+
+func TestEngine_SearchBatch_SameGTINInOtherForms(t *testing.T) {
+	scraper := &indexedScraper{products: map[string]core.Product{"7898215151784": {Name: "Café", GTIN: "7898215151784"}}}
+	engine := core.NewEngine(core.WithScrapers(scraper))
+
+	results, err := engine.SearchBatch(context.Background(), []string{"7898215151784", "07898215151784", " 7898215151784 "})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"7898215151784"}, scraper.queries, "one lookup per GTIN")
+	for _, query := range []string{"7898215151784", "07898215151784", " 7898215151784 "} {
+		if assert.Contains(t, results, query) && assert.Len(t, results[query].Products, 1) {
+			assert.Equal(t, query, results[query].Query)
+			assert.Equal(t, "Café", results[query].Products[0].Name)
+		}
+	}
+
+	results["07898215151784"].Products[0].Name = "Modified"
+	assert.Equal(t, "Café", results["7898215151784"].Products[0].Name, "each query gets its own result")
+}
@@ -0,0 +1,208 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type (
+	// BreakerState is the state of a scraper circuit breaker
+	BreakerState string
+
+	// BreakerConfig tunes the circuit breakers of the Engine
+	BreakerConfig struct {
+		// FailureThreshold is the number of consecutive failed searches that
+		// opens the circuit. Defaults to 5.
+		FailureThreshold int
+		// OpenTimeout is how long the circuit stays open before letting a
+		// probe through. Defaults to 30 seconds.
+		OpenTimeout time.Duration
+		// HalfOpenSuccesses is the number of successful probes that closes the
+		// circuit again. Defaults to 1.
+		HalfOpenSuccesses int
+		// TripOnPanic opens the circuit as soon as the scraper panics, whatever
+		// the FailureThreshold
+		TripOnPanic bool
+	}
+
+	// BreakerSnapshot is the observable state of a circuit breaker
+	BreakerSnapshot struct {
+		State    BreakerState
+		Failures int
+		OpenedAt time.Time
+		LastErr  error
+	}
+
+	// breaker is a per-scraper circuit breaker. Not found answers count as
+	// successes, since the website did answer.
+	breaker struct {
+		mu        sync.Mutex
+		config    BreakerConfig
+		state     BreakerState
+		failures  int
+		successes int
+		probing   bool
+		openedAt  time.Time
+		lastErr   error
+	}
+)
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+var ErrCircuitOpen = errors.New("circuit open")
+
+// WithCircuitBreaker enables a circuit breaker per scraper. Searches skip the
+// scrapers whose circuit is open and report them with ErrCircuitOpen. Zero
+// fields of config take their documented defaults.
+func WithCircuitBreaker(config BreakerConfig) Option {
+	return func(e *Engine) {
+		if config.FailureThreshold <= 0 {
+			config.FailureThreshold = 5
+		}
+		if config.OpenTimeout <= 0 {
+			config.OpenTimeout = 30 * time.Second
+		}
+		if config.HalfOpenSuccesses <= 0 {
+			config.HalfOpenSuccesses = 1
+		}
+		e.config.Breaker = &config
+	}
+}
+
+// Breakers returns the state of every circuit breaker used so far, keyed by
+// Website ID
+func (e *Engine) Breakers() map[string]BreakerSnapshot {
+	e.breakersMutex.Lock()
+	defer e.breakersMutex.Unlock()
+
+	snapshots := make(map[string]BreakerSnapshot, len(e.breakers))
+	for id, b := range e.breakers {
+		snapshots[id] = b.snapshot()
+	}
+	return snapshots
+}
+
+// Breaker returns the state of the circuit breaker of a scraper. Scrapers not
+// used yet are reported closed.
+func (e *Engine) Breaker(id string) BreakerSnapshot {
+	e.breakersMutex.Lock()
+	b, ok := e.breakers[id]
+	e.breakersMutex.Unlock()
+
+	if !ok {
+		return BreakerSnapshot{State: BreakerClosed}
+	}
+	return b.snapshot()
+}
+
+// ResetBreaker closes the circuit of a scraper and forgets its failures
+func (e *Engine) ResetBreaker(id string) {
+	e.breakersMutex.Lock()
+	b, ok := e.breakers[id]
+	e.breakersMutex.Unlock()
+
+	if ok {
+		b.reset()
+	}
+}
+
+// breaker returns the circuit breaker of website, or nil when breakers are
+// disabled
+func (e *Engine) breaker(website Website) *breaker {
+	if e.config.Breaker == nil {
+		return nil
+	}
+
+	e.breakersMutex.Lock()
+	defer e.breakersMutex.Unlock()
+
+	if e.breakers == nil {
+		e.breakers = map[string]*breaker{}
+	}
+	b, ok := e.breakers[website.key()]
+	if !ok {
+		b = &breaker{config: *e.config.Breaker, state: BreakerClosed}
+		e.breakers[website.key()] = b
+	}
+	return b
+}
+
+// allow reports whether a request may go through. In half-open state only
+// one probe is let through at a time.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.config.OpenTimeout {
+			return false
+		}
+		b.state, b.successes = BreakerHalfOpen, 0
+		fallthrough
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record updates the breaker with the outcome of an allowed request
+func (b *breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		// The caller gave up, this says nothing about the website
+	case err == nil || errors.Is(err, ErrProductNotFound):
+		b.failures = 0
+		if b.state == BreakerHalfOpen {
+			b.successes++
+			if b.successes >= b.config.HalfOpenSuccesses {
+				b.state = BreakerClosed
+			}
+		}
+	default:
+		b.failures++
+		b.lastErr = err
+		panicked := errors.As(err, new(*PanicError))
+		if b.state == BreakerHalfOpen || b.failures >= b.config.FailureThreshold || panicked && b.config.TripOnPanic {
+			b.trip()
+		}
+	}
+}
+
+func (b *breaker) trip() {
+	b.state, b.openedAt, b.successes = BreakerOpen, time.Now(), 0
+}
+
+func (b *breaker) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state, b.failures, b.successes, b.probing, b.lastErr = BreakerClosed, 0, 0, false, nil
+}
+
+func (b *breaker) snapshot() BreakerSnapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.state
+	if state == BreakerOpen && time.Since(b.openedAt) >= b.config.OpenTimeout {
+		state = BreakerHalfOpen // Next request will probe
+	}
+	return BreakerSnapshot{State: state, Failures: b.failures, OpenedAt: b.openedAt, LastErr: b.lastErr}
+}
@@ -0,0 +1,79 @@
+package core_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	core "achapromo.com/productscout"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngine_CircuitBreaker(t *testing.T) {
+	flaky := &flakyScraper{id: "flaky.example", failures: 2}
+	engine := core.NewEngine(
+		core.WithCircuitBreaker(core.BreakerConfig{FailureThreshold: 2, OpenTimeout: 30 * time.Millisecond}),
+		core.WithScrapers(flaky),
+	)
+	search := func() core.SourceReport {
+		result, err := engine.SearchWithReport(context.Background(), "7898215151784")
+		if result.Failed() {
+			assert.ErrorIs(t, err, result.Sources[0].Err)
+		} else {
+			assert.NoError(t, err)
+		}
+		return result.Sources[0]
+	}
+
+	assert.Equal(t, core.StatusError, search().Status)
+	assert.Equal(t, core.BreakerClosed, engine.Breaker("flaky.example").State)
+	assert.Equal(t, core.StatusError, search().Status)
+	assert.Equal(t, core.BreakerOpen, engine.Breaker("flaky.example").State)
+
+	skipped := search()
+	assert.Equal(t, core.StatusSkipped, skipped.Status)
+	assert.ErrorIs(t, skipped.Err, core.ErrCircuitOpen)
+	assert.Equal(t, "skipped: circuit open", skipped.String())
+	assert.Equal(t, 2, flaky.calls)
+
+	time.Sleep(40 * time.Millisecond)
+	assert.Equal(t, core.BreakerHalfOpen, engine.Breaker("flaky.example").State)
+	assert.Equal(t, core.StatusOK, search().Status)
+	assert.Equal(t, core.BreakerClosed, engine.Breaker("flaky.example").State)
+}
+
+func TestEngine_ResetBreaker(t *testing.T) {
+	flaky := &flakyScraper{id: "flaky.example", failures: 1}
+	engine := core.NewEngine(
+		core.WithCircuitBreaker(core.BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Hour}),
+		core.WithScrapers(flaky),
+	)
+
+	_, _ = engine.Search("7898215151784")
+	assert.Equal(t, core.BreakerOpen, engine.Breakers()["flaky.example"].State)
+
+	engine.ResetBreaker("flaky.example")
+
+	products, err := engine.Search("7898215151784")
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, core.BreakerClosed, engine.Breakers()["flaky.example"].State)
+}
+
+func TestWithCircuitBreaker_Defaults(t *testing.T) {
+	flaky := &flakyScraper{id: "flaky.example", failures: 10}
+	engine := core.NewEngine(
+		core.WithCircuitBreaker(core.BreakerConfig{}),
+		core.WithScrapers(flaky),
+	)
+
+	for i := 0; i < 4; i++ {
+		_, _ = engine.Search("7898215151784")
+		assert.Equal(t, core.BreakerClosed, engine.Breaker("flaky.example").State)
+	}
+	_, _ = engine.Search("7898215151784")
+
+	assert.Equal(t, core.BreakerOpen, engine.Breaker("flaky.example").State)
+	report, _ := engine.SearchWithReport(context.Background(), "7898215151784")
+	assert.ErrorIs(t, report.Sources[0].Err, core.ErrCircuitOpen)
+}
@@ -0,0 +1,139 @@
+package core
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"achapromo.com/productscout/gtin"
+)
+
+type (
+	// Cache stores scraper answers between searches. Implementations, such as
+	// the ones of the cache package, must be safe for concurrent use.
+	Cache interface {
+		// Get returns the entry stored under key, if it has not expired
+		Get(key string) (CacheEntry, bool, error)
+		// Set stores entry under key for ttl
+		Set(key string, entry CacheEntry, ttl time.Duration) error
+	}
+
+	// CacheEntry is a cached scraper answer: either its products or a
+	// definitive not found
+	CacheEntry struct {
+		Products []Product `json:"products,omitempty"`
+		NotFound bool      `json:"not_found,omitempty"`
+		// CachedAt is when the scraper gave the answer; its prices are as old
+		CachedAt time.Time `json:"cached_at"`
+	}
+
+	// CacheTTL sets how long answers stay cached. A zero TTL disables caching
+	// for that kind of answer.
+	CacheTTL struct {
+		// Hit applies to answers with products
+		Hit time.Duration
+		// Miss applies to ErrProductNotFound answers
+		Miss time.Duration
+	}
+
+	// CacheLookupEvent is emitted each time the Engine looks a scraper answer
+	// up in its cache
+	CacheLookupEvent struct {
+		Query   string
+		Website Website
+		Hit     bool
+	}
+)
+
+// WithCache caches the answers of every scraper in c. Failures and timeouts
+// are never cached. A cached answer is served even when the circuit of its
+// scraper is open.
+func WithCache(c Cache, ttl CacheTTL) Option {
+	return func(e *Engine) {
+		e.config.Cache, e.config.CacheTTL = c, ttl
+	}
+}
+
+// CacheKey is the key under which the answer of website for query is cached.
+// Barcodes are keyed by their canonical form and text by its lowercase words.
+func CacheKey(website Website, query string) string {
+	return website.key() + "|" + canonicalQuery(query)
+}
+
+// canonicalQuery returns the same string for queries that only differ by the
+// form of their barcode, or by the case and spacing of their text
+func canonicalQuery(query string) string {
+	if g, err := gtin.Parse(query); err == nil {
+		return g.String()
+	}
+	return strings.ToLower(strings.Join(strings.Fields(query), " "))
+}
+
+// cached looks the answer of website for product up in the cache
+func (e *Engine) cached(ctx context.Context, website Website, product string) (CacheEntry, bool) {
+	if e.config.Cache == nil {
+		return CacheEntry{}, false
+	}
+
+	entry, ok, err := e.config.Cache.Get(CacheKey(website, product))
+	if err != nil {
+		e.config.Logger.Warn("cache lookup failed", "scraper", website.key(), "query", product, "err", err)
+	}
+	e.cacheLookup(ctx, CacheLookupEvent{Query: product, Website: website, Hit: ok})
+	if ok {
+		entry = entry.Clone() // The caller may modify the products
+	}
+	return entry, ok
+}
+
+// store caches the answer of website for product, when it deserves it
+func (e *Engine) store(website Website, product string, products []Product, err error) {
+	if e.config.Cache == nil {
+		return
+	}
+
+	entry, ttl := cacheEntry(products, err, e.config.CacheTTL)
+	if ttl <= 0 {
+		return
+	}
+
+	if err := e.config.Cache.Set(CacheKey(website, product), entry, ttl); err != nil {
+		e.config.Logger.Warn("cache store failed", "scraper", website.key(), "query", product, "err", err)
+	}
+}
+
+// cacheEntry returns the entry caching an answer and how long to keep it. A
+// zero duration means the answer must not be cached.
+func cacheEntry(products []Product, err error, ttl CacheTTL) (CacheEntry, time.Duration) {
+	switch {
+	case err == nil && len(products) > 0:
+		return CacheEntry{Products: cloneProducts(products), CachedAt: time.Now()}, ttl.Hit
+	case statusOf(err) == StatusNotFound:
+		return CacheEntry{NotFound: true, CachedAt: time.Now()}, ttl.Miss
+	}
+	return CacheEntry{}, 0
+}
+
+// Clone returns a copy of e sharing no memory with it, so a cache can hand
+// out entries without its callers modifying what it holds
+func (e CacheEntry) Clone() CacheEntry {
+	e.Products = cloneProducts(e.Products)
+	return e
+}
+
+// cloneProducts copies products along with their images, categories and
+// offers
+func cloneProducts(products []Product) []Product {
+	if products == nil {
+		return nil
+	}
+	clones := make([]Product, len(products))
+	for i, product := range products {
+		product.Images = slices.Clone(product.Images)
+		product.Categories = slices.Clone(product.Categories)
+		product.Offers = slices.Clone(product.Offers)
+		clones[i] = product
+	}
+	return clones
+}
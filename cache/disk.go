@@ -0,0 +1,189 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	core "achapromo.com/productscout"
+)
+
+type (
+	// Disk is a cache persisted in a single directory, so answers survive
+	// restarts. Each entry is a JSON file named after the SHA-256 of its key,
+	// holding its expiry next to the answer. Expired entries are dropped when
+	// looked up or by Compact.
+	Disk struct {
+		dir   string
+		mu    sync.Mutex // Guards stats, and renames against removals
+		stats Stats
+	}
+
+	diskEntry struct {
+		Key       string          `json:"key"`
+		ExpiresAt time.Time       `json:"expires_at"`
+		Entry     core.CacheEntry `json:"entry"`
+	}
+)
+
+const diskExt = ".json"
+
+// NewDisk opens the cache stored in dir, creating the directory if needed
+func NewDisk(dir string) (*Disk, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("cache directory: %w", err)
+	}
+	return &Disk{dir: dir}, nil
+}
+
+// Get returns the entry stored under key, if it has not expired. Unreadable
+// entries are removed and reported as an error along with a miss.
+func (d *Disk) Get(key string) (core.CacheEntry, bool, error) {
+	path := d.path(key)
+	cached, err := readEntry(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		d.count(func(s *Stats) { s.Misses++ })
+		return core.CacheEntry{}, false, nil
+	case err != nil:
+		d.removeStale(path, time.Now())
+		d.count(func(s *Stats) { s.Misses++ })
+		return core.CacheEntry{}, false, err
+	case cached.Key != key: // A SHA-256 collision, or a file copied over
+		d.count(func(s *Stats) { s.Misses++ })
+		return core.CacheEntry{}, false, nil
+	case !time.Now().Before(cached.ExpiresAt):
+		d.removeStale(path, time.Now())
+		d.count(func(s *Stats) { s.Misses++; s.Expired++ })
+		return core.CacheEntry{}, false, nil
+	}
+
+	d.count(func(s *Stats) { s.Hits++ })
+	return cached.Entry, true, nil
+}
+
+// Set stores entry under key for ttl. The file is written aside and renamed,
+// so readers never see a partial entry.
+func (d *Disk) Set(key string, entry core.CacheEntry, ttl time.Duration) error {
+	data, err := json.Marshal(diskEntry{Key: key, ExpiresAt: time.Now().Add(ttl), Entry: entry})
+	if err != nil {
+		return fmt.Errorf("cache entry %s: %w", key, err)
+	}
+
+	tmp, err := os.CreateTemp(d.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return os.Rename(tmp.Name(), d.path(key))
+}
+
+// Compact removes the expired and unreadable entries, and the temporary files
+// left by interrupted writes, returning how many files it removed
+func (d *Disk) Compact() (int, error) {
+	files, err := os.ReadDir(d.dir)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	now := time.Now()
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		path := filepath.Join(d.dir, file.Name())
+
+		switch {
+		case strings.HasPrefix(file.Name(), ".tmp-"):
+			if info, err := file.Info(); err != nil || now.Sub(info.ModTime()) < time.Minute {
+				continue // Possibly a write in progress
+			}
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return removed, err
+			}
+			removed++
+		case strings.HasSuffix(file.Name(), diskExt):
+			stale, err := d.removeStale(path, now)
+			if err != nil {
+				return removed, err
+			}
+			if stale {
+				removed++
+			}
+		}
+	}
+
+	d.count(func(s *Stats) { s.Evictions += removed })
+	return removed, nil
+}
+
+// Stats returns the usage of the cache since it was opened. Entries counts the
+// files currently in the directory, expired ones included until compacted.
+func (d *Disk) Stats() Stats {
+	d.mu.Lock()
+	stats := d.stats
+	d.mu.Unlock()
+
+	files, _ := filepath.Glob(filepath.Join(d.dir, "*"+diskExt))
+	stats.Entries = len(files)
+	return stats
+}
+
+// removeStale removes the entry at path if it is expired or unreadable. It
+// reads the file again under the lock Set renames with, so an entry written
+// since the caller read it is never removed. It reports whether it removed it.
+func (d *Disk) removeStale(path string, now time.Time) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if cached, err := readEntry(path); errors.Is(err, fs.ErrNotExist) || err == nil && now.Before(cached.ExpiresAt) {
+		return false, nil
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+	return true, nil
+}
+
+func (d *Disk) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:])+diskExt)
+}
+
+func (d *Disk) count(update func(*Stats)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	update(&d.stats)
+}
+
+func readEntry(path string) (diskEntry, error) {
+	var cached diskEntry
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cached, err
+	}
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return cached, fmt.Errorf("cache file %s: %w", filepath.Base(path), err)
+	}
+	return cached, nil
+}
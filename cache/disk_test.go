@@ -0,0 +1,90 @@
+package cache_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	core "achapromo.com/productscout"
+	"achapromo.com/productscout/cache"
+	"achapromo.com/productscout/gtin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisk_SurvivesRestarts(t *testing.T) {
+	dir := t.TempDir()
+	product := core.Product{
+		Name:   "Creme de Leite Piracanjuba 200g",
+		GTIN:   "7898215151784",
+		Offers: []core.Offer{{Store: "Pague Menos", Price: 4.99, ObservedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)}},
+		Source: core.Website{ID: "paguemenos.com.br", GTINFormats: []gtin.Format{gtin.EAN13}},
+	}
+
+	first, err := cache.NewDisk(dir)
+	assert.NoError(t, err)
+	assert.NoError(t, first.Set("paguemenos.com.br|7898215151784", core.CacheEntry{Products: []core.Product{product}}, time.Minute))
+
+	second, err := cache.NewDisk(dir)
+	assert.NoError(t, err)
+	entry, ok, err := second.Get("paguemenos.com.br|7898215151784")
+
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []core.Product{product}, entry.Products)
+	assert.Equal(t, cache.Stats{Hits: 1, Entries: 1}, second.Stats())
+}
+
+func TestDisk_Expires(t *testing.T) {
+	c, err := cache.NewDisk(t.TempDir())
+	assert.NoError(t, err)
+
+	c.Set("a", core.CacheEntry{NotFound: true}, -time.Second) // Written already expired
+
+	_, ok, err := c.Get("a")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, cache.Stats{Misses: 1, Expired: 1}, c.Stats())
+}
+
+func TestDisk_CorruptedEntry(t *testing.T) {
+	dir := t.TempDir()
+	c, err := cache.NewDisk(dir)
+	assert.NoError(t, err)
+	c.Set("a", core.CacheEntry{NotFound: true}, time.Minute)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if !assert.Len(t, files, 1) {
+		return
+	}
+	os.WriteFile(files[0], []byte("{truncated"), 0o644)
+
+	_, ok, err := c.Get("a")
+	assert.Error(t, err)
+	assert.False(t, ok)
+	assert.NoFileExists(t, files[0])
+}
+
+func TestDisk_Compact(t *testing.T) {
+	dir := t.TempDir()
+	c, err := cache.NewDisk(dir)
+	assert.NoError(t, err)
+
+	c.Set("fresh", core.CacheEntry{NotFound: true}, time.Minute)
+	c.Set("stale", core.CacheEntry{NotFound: true}, -time.Second)
+	os.WriteFile(filepath.Join(dir, "broken.json"), []byte("not json"), 0o644)
+	os.WriteFile(filepath.Join(dir, "README"), []byte("kept"), 0o644)
+	leftover := filepath.Join(dir, ".tmp-123")
+	os.WriteFile(leftover, []byte("{"), 0o644)
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(leftover, old, old)
+
+	removed, err := c.Compact()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, removed)
+	assert.Equal(t, 1, c.Stats().Entries)
+	assert.FileExists(t, filepath.Join(dir, "README"))
+	_, ok, _ := c.Get("fresh")
+	assert.True(t, ok)
+}
@@ -0,0 +1,8 @@
+package cache
+
+import "time"
+
+// SetClock makes m read the time from now, to expire entries without waiting
+func SetClock(m *Memory, now func() time.Time) {
+	m.now = now
+}
@@ -0,0 +1,109 @@
+// Package cache provides core.Cache implementations.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	core "achapromo.com/productscout"
+)
+
+type (
+	// Memory is an in-memory LRU cache holding at most a fixed number of
+	// entries. Expired entries are dropped when looked up or evicted.
+	Memory struct {
+		mu      sync.Mutex
+		size    int
+		entries map[string]*list.Element
+		order   *list.List // Most recently used first
+		stats   Stats
+		now     func() time.Time
+	}
+
+	memoryEntry struct {
+		key       string
+		entry     core.CacheEntry
+		expiresAt time.Time
+	}
+
+	// Stats describes how a cache has been used, to tune its size and TTLs
+	Stats struct {
+		Hits      int
+		Misses    int
+		Expired   int // Lookups that found an expired entry, also counted as misses
+		Evictions int
+		Entries   int
+	}
+)
+
+// NewMemory creates a Memory cache holding at most size entries
+func NewMemory(size int) *Memory {
+	return &Memory{
+		size:    max(size, 1),
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+// Get returns the entry stored under key, if it has not expired
+func (m *Memory) Get(key string) (core.CacheEntry, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		m.stats.Misses++
+		return core.CacheEntry{}, false, nil
+	}
+
+	cached := element.Value.(*memoryEntry)
+	if !m.now().Before(cached.expiresAt) {
+		m.remove(element)
+		m.stats.Misses++
+		m.stats.Expired++
+		return core.CacheEntry{}, false, nil
+	}
+
+	m.order.MoveToFront(element)
+	m.stats.Hits++
+	return cached.entry.Clone(), true, nil
+}
+
+// Set stores entry under key for ttl, evicting the least recently used entry
+// when the cache is full
+func (m *Memory) Set(key string, entry core.CacheEntry, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry = entry.Clone()
+	expiresAt := m.now().Add(ttl)
+	if element, ok := m.entries[key]; ok {
+		element.Value = &memoryEntry{key: key, entry: entry, expiresAt: expiresAt}
+		m.order.MoveToFront(element)
+		return nil
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryEntry{key: key, entry: entry, expiresAt: expiresAt})
+	for m.order.Len() > m.size {
+		m.remove(m.order.Back())
+		m.stats.Evictions++
+	}
+	return nil
+}
+
+// Stats returns the usage of the cache so far
+func (m *Memory) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.stats
+	stats.Entries = m.order.Len()
+	return stats
+}
+
+func (m *Memory) remove(element *list.Element) {
+	m.order.Remove(element)
+	delete(m.entries, element.Value.(*memoryEntry).key)
+}
@@ -0,0 +1,85 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	core "achapromo.com/productscout"
+	"achapromo.com/productscout/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory_GetSet(t *testing.T) {
+	c := cache.NewMemory(10)
+
+	_, ok, err := c.Get("a")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, c.Set("a", core.CacheEntry{Products: []core.Product{{Name: "A"}}}, time.Minute))
+	entry, ok, err := c.Get("a")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "A", entry.Products[0].Name)
+
+	entry.Products[0].Name = "Modified"
+	entry, _, _ = c.Get("a")
+	assert.Equal(t, "A", entry.Products[0].Name, "callers get their own copy")
+
+	assert.Equal(t, cache.Stats{Hits: 2, Misses: 1, Entries: 1}, c.Stats())
+}
+
+func TestMemory_DeepCopies(t *testing.T) {
+	c := cache.NewMemory(10)
+	stored := core.CacheEntry{Products: []core.Product{{
+		Name:       "A",
+		Images:     []string{"a.jpg"},
+		Categories: []string{"Food"},
+		Offers:     []core.Offer{{Seller: "Store", Price: 3.49}},
+	}}}
+	assert.NoError(t, c.Set("a", stored, time.Minute))
+	stored.Products[0].Offers[0].Price = 0
+
+	entry, _, _ := c.Get("a")
+	entry.Products[0].Images[0] = "modified.jpg"
+	entry.Products[0].Categories[0] = "Modified"
+	entry, _, _ = c.Get("a")
+
+	assert.Equal(t, 3.49, entry.Products[0].Offers[0].Price)
+	assert.Equal(t, []string{"a.jpg"}, entry.Products[0].Images)
+	assert.Equal(t, []string{"Food"}, entry.Products[0].Categories)
+}
+
+func TestMemory_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := cache.NewMemory(2)
+
+	c.Set("a", core.CacheEntry{NotFound: true}, time.Minute)
+	c.Set("b", core.CacheEntry{NotFound: true}, time.Minute)
+	c.Get("a") // b is now the least recently used
+	c.Set("c", core.CacheEntry{NotFound: true}, time.Minute)
+
+	_, okA, _ := c.Get("a")
+	_, okB, _ := c.Get("b")
+	_, okC, _ := c.Get("c")
+	assert.True(t, okA)
+	assert.False(t, okB)
+	assert.True(t, okC)
+	assert.Equal(t, 1, c.Stats().Evictions)
+	assert.Equal(t, 2, c.Stats().Entries)
+}
+
+func TestMemory_Expires(t *testing.T) {
+	c := cache.NewMemory(10)
+	now := time.Now()
+	cache.SetClock(c, func() time.Time { return now })
+
+	c.Set("a", core.CacheEntry{NotFound: true}, time.Minute)
+	now = now.Add(time.Minute - time.Nanosecond)
+	_, ok, _ := c.Get("a")
+	assert.True(t, ok)
+
+	now = now.Add(time.Nanosecond)
+	_, ok, _ = c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, cache.Stats{Hits: 1, Misses: 1, Expired: 1}, c.Stats())
+}
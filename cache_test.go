@@ -0,0 +1,81 @@
+package core_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	core "achapromo.com/productscout"
+	"achapromo.com/productscout/cache"
+	"achapromo.com/productscout/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngine_Cache_Hits(t *testing.T) {
+	flaky := &flakyScraper{id: "flaky.example"}
+	registry := metrics.NewRegistry()
+	engine := core.NewEngine(
+		core.WithMetrics(registry),
+		core.WithCache(cache.NewMemory(10), core.CacheTTL{Hit: time.Minute}),
+		core.WithScrapers(flaky),
+	)
+
+	first, err := engine.SearchWithReport(context.Background(), "7898215151784")
+	assert.NoError(t, err)
+	second, err := engine.SearchWithReport(context.Background(), "07898215151784")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, flaky.calls)
+	assert.False(t, first.Sources[0].Cached)
+	assert.True(t, first.Sources[0].CachedAt.IsZero())
+	assert.True(t, second.Sources[0].Cached)
+	assert.WithinDuration(t, time.Now(), second.Sources[0].CachedAt, time.Second, "the report tells how old the cached prices are")
+	assert.Equal(t, core.StatusOK, second.Sources[0].Status)
+	assert.Equal(t, first.Products, second.Products)
+
+	lookups := registry.Counter("productscout_cache_lookups_total", "", "scraper", "result")
+	assert.Equal(t, float64(1), lookups.Value("flaky.example", "hit"))
+	assert.Equal(t, float64(1), lookups.Value("flaky.example", "miss"))
+}
+
+func TestEngine_Cache_NotFound(t *testing.T) {
+	scraper := &indexedScraper{}
+	engine := core.NewEngine(
+		core.WithCache(cache.NewMemory(10), core.CacheTTL{Hit: time.Minute, Miss: time.Minute}),
+		core.WithScrapers(scraper),
+	)
+
+	engine.Search("7898215151784")
+	result, err := engine.SearchWithReport(context.Background(), "7898215151784")
+
+	assert.NoError(t, err)
+	assert.Len(t, scraper.queries, 1)
+	assert.True(t, result.Sources[0].Cached)
+	assert.ErrorIs(t, result.Sources[0].Err, core.ErrProductNotFound)
+}
+
+func TestEngine_Cache_SkipsFailuresAndDisabledTTL(t *testing.T) {
+	flaky := &flakyScraper{id: "flaky.example", failures: 1}
+	missing := &indexedScraper{}
+	engine := core.NewEngine(
+		core.WithLogger(core.NewLogger(discardHandler())),
+		core.WithCache(cache.NewMemory(10), core.CacheTTL{Hit: time.Minute}),
+		core.WithScrapers(flaky, missing),
+	)
+
+	engine.Search("7898215151784")
+	result, err := engine.SearchWithReport(context.Background(), "7898215151784")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, flaky.calls, "failures are not cached")
+	assert.Len(t, missing.queries, 2, "not found answers are not cached without a Miss TTL")
+	assert.Equal(t, core.StatusOK, result.Sources[0].Status)
+}
+
+func TestCacheKey(t *testing.T) {
+	website := core.Website{ID: "a.example"}
+
+	assert.Equal(t, core.CacheKey(website, "7898215151784"), core.CacheKey(website, "07898215151784"))
+	assert.Equal(t, core.CacheKey(website, "Creme de  Leite"), core.CacheKey(website, "creme de leite"))
+	assert.NotEqual(t, core.CacheKey(website, "7898215151784"), core.CacheKey(core.Website{ID: "b.example"}, "7898215151784"))
+}
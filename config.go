@@ -0,0 +1,126 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// ConfigFile describes an Engine and its scrapers, as loaded from a JSON
+	// or YAML file
+	ConfigFile struct {
+		Debug                    bool            `json:"debug" yaml:"debug"`
+		MaxConcurrency           int             `json:"max_concurrency" yaml:"max_concurrency"`
+		MaxConcurrencyPerScraper int             `json:"max_concurrency_per_scraper" yaml:"max_concurrency_per_scraper"`
+		Timeout                  Duration        `json:"timeout" yaml:"timeout"`
+		Tiers                    [][]string      `json:"tiers,omitempty" yaml:"tiers,omitempty"`
+		Scrapers                 []ScraperConfig `json:"scrapers" yaml:"scrapers"`
+	}
+
+	// ScraperConfig selects a registered scraper by id along with its settings
+	// and, optionally, the policy the Engine applies to it
+	ScraperConfig struct {
+		ID       string `json:"id" yaml:"id"`
+		Settings `yaml:",inline"`
+		Policy   *PolicyConfig `json:"policy,omitempty" yaml:"policy,omitempty"`
+	}
+
+	// PolicyConfig is the config file form of a ScraperPolicy
+	PolicyConfig struct {
+		Timeout Duration `json:"timeout" yaml:"timeout"`
+		Retries int      `json:"retries" yaml:"retries"`
+		Backoff Duration `json:"backoff" yaml:"backoff"`
+	}
+
+	// Duration is a time.Duration written as "1m30s" in config files
+	Duration time.Duration
+)
+
+// LoadConfigFile reads a config file. Files ending in .yaml or .yml are parsed
+// as YAML, anything else as JSON.
+func LoadConfigFile(path string) (*ConfigFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg ConfigFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &cfg)
+	default:
+		err = json.Unmarshal(data, &cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// NewEngineFromConfig builds an Engine with the scrapers listed in cfg, which
+// must be registered. opts are applied after the config, so they win.
+func NewEngineFromConfig(cfg ConfigFile, opts ...Option) (*Engine, error) {
+	base := []Option{
+		WithMaxConcurrency(cfg.MaxConcurrency),
+		WithMaxConcurrencyPerScraper(cfg.MaxConcurrencyPerScraper),
+		WithTimeout(time.Duration(cfg.Timeout)),
+	}
+	if cfg.Debug {
+		base = append(base, WithDebug())
+	}
+	if len(cfg.Tiers) > 0 {
+		base = append(base, WithTiers(cfg.Tiers...))
+	}
+
+	// The scrapers are built after opts, to share the metrics registry they
+	// set, but still come first and yield to the policies set by opts
+	var err error
+	scrapers := func(e *Engine) {
+		built := make([]Scraper, 0, len(cfg.Scrapers))
+		for _, sc := range cfg.Scrapers {
+			if sc.Settings.Metrics == nil {
+				sc.Settings.Metrics = e.config.Metrics
+			}
+			var scraper Scraper
+			if scraper, err = NewScraper(sc.ID, sc.Settings); err != nil {
+				return
+			}
+			built = append(built, scraper)
+
+			key := scraper.Info().key()
+			if _, set := e.config.Policies[key]; sc.Policy != nil && !set {
+				WithScraperPolicy(key, ScraperPolicy{
+					Timeout: time.Duration(sc.Policy.Timeout),
+					Retries: sc.Policy.Retries,
+					Backoff: time.Duration(sc.Policy.Backoff),
+				})(e)
+			}
+		}
+		e.scrapers = append(built, e.scrapers...)
+	}
+
+	e := NewEngine(append(append(base, opts...), scrapers)...)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
@@ -0,0 +1,91 @@
+package core_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	core "achapromo.com/productscout"
+	"achapromo.com/productscout/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+// meteredWith is the registry the last metered.example scraper was built with
+var meteredWith *metrics.Registry
+
+func init() {
+	core.Register("configured.example", func(settings core.Settings) (core.Scraper, error) {
+		return &fakeScraper{url: settings.BaseURL, products: []core.Product{{Name: settings.APIKey, GTIN: "7898215151784"}}}, nil
+	})
+	core.Register("metered.example", func(settings core.Settings) (core.Scraper, error) {
+		meteredWith = settings.Metrics
+		return &fakeScraper{url: "https://metered.example"}, nil
+	})
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"engine.yaml": "max_concurrency: 3\ntimeout: 2s\nscrapers:\n  - id: configured.example\n    base_url: https://mirror.example\n    api_key: secret\n    timeout: 500ms\n    headers:\n      x-token: abc\n",
+		"engine.json": `{"max_concurrency": 3, "timeout": "2s", "scrapers": [{"id": "configured.example", "base_url": "https://mirror.example", "api_key": "secret", "timeout": "500ms", "headers": {"x-token": "abc"}}]}`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+			cfg, err := core.LoadConfigFile(path)
+
+			assert.NoError(t, err)
+			assert.Equal(t, 3, cfg.MaxConcurrency)
+			assert.Equal(t, core.Duration(2*time.Second), cfg.Timeout)
+			assert.Equal(t, []core.ScraperConfig{{
+				ID: "configured.example",
+				Settings: core.Settings{
+					BaseURL: "https://mirror.example",
+					APIKey:  "secret",
+					Timeout: core.Duration(500 * time.Millisecond),
+					Headers: map[string]string{"x-token": "abc"},
+				},
+			}}, cfg.Scrapers)
+		})
+	}
+}
+
+func TestNewEngineFromConfig(t *testing.T) {
+	engine, err := core.NewEngineFromConfig(core.ConfigFile{Scrapers: []core.ScraperConfig{
+		{ID: "configured.example", Settings: core.Settings{BaseURL: "https://mirror.example", APIKey: "secret"}},
+	}})
+	assert.NoError(t, err)
+
+	products, err := engine.Search("7898215151784")
+
+	assert.NoError(t, err)
+	if assert.Len(t, products, 1) {
+		assert.Equal(t, "secret", products[0].Name)
+		assert.Equal(t, "https://mirror.example", products[0].Source.URL)
+	}
+}
+
+func TestNewEngineFromConfig_SharesMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	applied := 0
+
+	_, err := core.NewEngineFromConfig(
+		core.ConfigFile{Scrapers: []core.ScraperConfig{{ID: "metered.example"}}},
+		core.WithMetrics(registry),
+		func(e *core.Engine) { applied++ },
+	)
+
+	assert.NoError(t, err)
+	assert.Same(t, registry, meteredWith)
+	assert.Equal(t, 1, applied, "each option runs once")
+}
+
+func TestNewEngineFromConfig_UnknownScraper(t *testing.T) {
+	_, err := core.NewEngineFromConfig(core.ConfigFile{Scrapers: []core.ScraperConfig{{ID: "missing.example"}}})
+
+	assert.ErrorIs(t, err, core.ErrUnknownScraper)
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"regexp"
+	"slices"
 	"sync"
 	"time"
+
+	"achapromo.com/productscout/gtin"
+	"achapromo.com/productscout/metrics"
 )
 
 type (
@@ -14,27 +17,69 @@ type (
 		Name string
 		GTIN string
 		URL  string
-	}
 
-	Website struct {
-		URL string
+		Brand      string
+		Quantity   string // Net content as printed by the source, e.g. "200 g"
+		Images     []string
+		Categories []string
+
+		// Price and ListPrice are the selling and the before-discount prices
+		// on the source, in Currency. Zero means the source has no price.
+		Price        float64
+		ListPrice    float64
+		Currency     string
+		Availability Availability
+
+		// Offers lists every price found for the product on the source
+		Offers []Offer
+
+		// Source is the website the product was found on. The Engine fills it
+		// when the scraper leaves it empty.
+		Source Website
 	}
 
+	// Scraper looks up a product on a single website. Implementations must
+	// honour ctx cancellation so the Engine can stop waiting on slow sources.
 	Scraper interface {
-		Scrape(product string) ([]Product, error)
+		Scrape(ctx context.Context, query string) ([]Product, error)
 		Info() Website
 	}
 
 	Config struct {
-		Debug          bool
-		Logger         Logger
-		MaxConcurrency int
-		Timeout        time.Duration
+		Debug                    bool
+		Logger                   Logger
+		MaxConcurrency           int
+		MaxConcurrencyPerScraper int
+		Timeout                  time.Duration
+		Progress                 func(BatchProgress)
+		DefaultPolicy            ScraperPolicy
+		Policies                 map[string]ScraperPolicy
+		Breaker                  *BreakerConfig
+		Tiers                    [][]string
+		Stop                     StopCondition
+		Strategy                 Strategy
+		Middleware               []Middleware
+		ScraperMiddleware        map[string][]Middleware
+		Hooks                    []Hooks
+		Metrics                  *metrics.Registry
+		Cache                    Cache
+		CacheTTL                 CacheTTL
+		Coalesce                 bool
 	}
 
 	Engine struct {
-		config   Config
-		scrapers []Scraper
+		config    Config
+		scrapers  []Scraper
+		setupOnce sync.Once
+
+		limiters      map[string]*rateLimiter
+		limitersMutex sync.Mutex
+
+		breakers      map[string]*breaker
+		breakersMutex sync.Mutex
+
+		searches flightGroup[*SearchResult]
+		inflight flightGroup[answer]
 	}
 
 	// Option configures the Engine
@@ -44,8 +89,6 @@ type (
 var (
 	ErrMissingScraper  = errors.New("missing scraper")
 	ErrProductNotFound = errors.New("product not found")
-
-	gtinRegex = regexp.MustCompile(`^\d{13}$`)
 )
 
 func NewEngine(opts ...Option) *Engine {
@@ -53,6 +96,9 @@ func NewEngine(opts ...Option) *Engine {
 	for _, opt := range opts {
 		opt(e)
 	}
+	for i, s := range e.scrapers { // Once the middlewares are all known
+		e.scrapers[i] = e.wrap(s)
+	}
 	return e
 }
 
@@ -83,98 +129,392 @@ func WithMaxConcurrency(n int) Option {
 	}
 }
 
+// WithMaxConcurrencyPerScraper limits how many requests a batch sends to the
+// same scraper at once. Zero means no limit besides MaxConcurrency.
+func WithMaxConcurrencyPerScraper(n int) Option {
+	return func(e *Engine) {
+		e.config.MaxConcurrencyPerScraper = n
+	}
+}
+
+// WithProgress sets a callback invoked by SearchBatch after each work item
+func WithProgress(fn func(BatchProgress)) Option {
+	return func(e *Engine) {
+		e.config.Progress = fn
+	}
+}
+
 func WithScrapers(s ...Scraper) Option {
 	return func(e *Engine) {
 		e.scrapers = append(e.scrapers, s...)
 	}
 }
 
+// setup fills the configuration defaults, once, so concurrent searches can
+// share the Engine
 func (e *Engine) setup() {
-	if e.config.Logger == nil {
-		e.config.Logger = &DefaultLogger{}
-	}
+	e.setupOnce.Do(func() {
+		if e.config.Logger == nil {
+			e.config.Logger = &DefaultLogger{}
+		}
 
-	if e.config.MaxConcurrency == 0 {
-		e.config.MaxConcurrency = 10
-	}
+		if e.config.MaxConcurrency == 0 {
+			e.config.MaxConcurrency = 10
+		}
+	})
 }
 
+// Search looks up product on every scraper. It is a shorthand for
+// SearchContext with a background context.
 func (e *Engine) Search(product string) ([]Product, error) {
+	return e.SearchContext(context.Background(), product)
+}
+
+// SearchContext looks up product on every scraper. Cancelling ctx, or reaching
+// the Engine timeout, is propagated to the scrapers still running.
+func (e *Engine) SearchContext(ctx context.Context, product string) ([]Product, error) {
+	result, err := e.SearchWithReport(ctx, product)
+	if result == nil {
+		return nil, err
+	}
+	return result.Products, err
+}
+
+// SearchWithReport looks up product on every scraper and returns the products
+// together with a report of how each scraper ended. When no scraper answered,
+// the error joins the reasons of every source, and the report still comes
+// along.
+func (e *Engine) SearchWithReport(ctx context.Context, product string) (*SearchResult, error) {
 	e.setup()
 
 	if len(e.scrapers) == 0 {
 		return nil, ErrMissingScraper
 	}
 
-	var ctx context.Context
+	if e.config.Coalesce {
+		return e.coalescedSearch(ctx, product)
+	}
+	return e.search(ctx, product)
+}
+
+// search runs a search for SearchWithReport
+func (e *Engine) search(ctx context.Context, product string) (*SearchResult, error) {
+	start := time.Now()
+	e.searchStarted(ctx, SearchStartedEvent{Query: product, Scrapers: len(e.scrapers)})
+
+	sources := make([]SourceReport, len(e.scrapers))
+	found := make([][]Product, len(e.scrapers)) // Each goroutine only writes its own index
+
+	err := e.run(ctx, product, func(idx int, report SourceReport, products []Product) {
+		sources[idx], found[idx] = report, products
+	})
+
+	results := []Product{}
+	for _, products := range found {
+		results = append(results, products...)
+	}
+	rankByText(product, results)
+
+	result := &SearchResult{Query: product, Products: results, Sources: sources}
+	if err == nil {
+		err = result.failure()
+	}
+	e.searchFinished(ctx, SearchFinishedEvent{Query: product, Result: result, Duration: time.Since(start), Err: err})
+	return result, err
+}
+
+// Stream looks up product on every scraper and emits each scraper's products
+// as soon as it answers. The last event on the channel has Done set and
+// carries the whole SearchResult; the channel is closed right after it.
+// The channel is buffered for every event, so callers may stop reading early.
+func (e *Engine) Stream(ctx context.Context, product string) <-chan SearchEvent {
+	e.setup()
+
+	events := make(chan SearchEvent, len(e.scrapers)+1)
+	if len(e.scrapers) == 0 {
+		events <- SearchEvent{Done: true, Err: ErrMissingScraper}
+		close(events)
+		return events
+	}
+
+	start := time.Now()
+	e.searchStarted(ctx, SearchStartedEvent{Query: product, Scrapers: len(e.scrapers)})
+
+	go func() {
+		defer close(events)
+
+		result := &SearchResult{Query: product, Products: []Product{}, Sources: make([]SourceReport, len(e.scrapers))}
+		var mu sync.Mutex
+
+		err := e.run(ctx, product, func(idx int, report SourceReport, products []Product) {
+			mu.Lock()
+			result.Sources[idx] = report
+			result.Products = append(result.Products, products...)
+			mu.Unlock()
+
+			events <- SearchEvent{Source: report, Products: products}
+		})
+
+		rankByText(product, result.Products)
+		if err == nil {
+			err = result.failure()
+		}
+		e.searchFinished(ctx, SearchFinishedEvent{Query: product, Result: result, Duration: time.Since(start), Err: err})
+		events <- SearchEvent{Done: true, Result: result, Err: err}
+	}()
+
+	return events
+}
+
+// run fans product out to every scraper, bounded by MaxConcurrency, and calls
+// emit once per scraper with its report and GTIN-filtered products. emit may
+// be called concurrently. With tiers, scrapers are queried one tier at a time
+// until the stop condition holds, and the completion strategy may end the
+// search before every scraper answered.
+func (e *Engine) run(ctx context.Context, product string, emit func(idx int, report SourceReport, products []Product)) error {
 	var cancel context.CancelFunc
 	if e.config.Timeout > 0 {
-		ctx, cancel = context.WithTimeout(context.Background(), e.config.Timeout)
+		ctx, cancel = context.WithTimeout(ctx, e.config.Timeout)
 	} else {
-		ctx, cancel = context.WithCancel(context.Background())
+		ctx, cancel = context.WithCancel(ctx)
 	}
 	defer cancel() // Ensure all paths cancel the context to avoid context leak
 
+	// done is cancelled once the strategy is satisfied, unlike ctx
+	done, finish := context.WithCancel(ctx)
+	defer finish()
+
 	var wg sync.WaitGroup
-	results := []Product{}
-	resultsMutex := &sync.Mutex{}
+	completed := &completion{strategy: e.config.Strategy, cancel: finish}
+	var completedMutex sync.Mutex
+	isSatisfied := func() bool {
+		completedMutex.Lock()
+		defer completedMutex.Unlock()
+		return completed.satisfied
+	}
 
 	// Create a buffered channel to limit the number of goroutines
 	semaphore := make(chan struct{}, e.config.MaxConcurrency)
 
-	for idx, scraper := range e.scrapers {
-		e.debug("Scraping", scraper.Info().URL)
-
-		select {
-		case semaphore <- struct{}{}: // Block if there are already MaxConcurrency goroutines running
-		case <-ctx.Done(): // Check if the context deadline has been reached
-			e.config.Logger.Error(fmt.Sprintf("timeout reached before starting all scrapers (exec: %d | non-exec: %d)", idx+1, len(e.scrapers[idx:])))
-			return results, ctx.Err()
+	tiers := e.tiers()
+	for t, tier := range tiers {
+		if t > 0 && (isSatisfied() || e.stop(completed.products)) {
+			e.debug("stopping after tier", "query", product, "tier", t)
+			skip(e.scrapers, tiers[t:], ErrNotNeeded, emit)
+			return nil
 		}
 
-		wg.Add(1)
-		go func(s Scraper) {
-			defer wg.Done()
-			defer func() { <-semaphore }() // Release the spot in the semaphore when the goroutine completes
-
-			if ctx.Err() == nil { // Check context error to avoid executing if already timed out
-				products, err := s.Scrape(product)
-				if err == nil {
-					resultsMutex.Lock()
-					results = append(results, products...)
-					resultsMutex.Unlock()
-				} else {
-					e.config.Logger.Error(fmt.Errorf("scraper %T failed: %w", s, err))
+		for n, idx := range tier {
+			scraper := e.scrapers[idx]
+			e.debug("scraping", "scraper", scraper.Info().key(), "query", product)
+
+			select {
+			case semaphore <- struct{}{}: // Block if there are already MaxConcurrency goroutines running
+			case <-done.Done(): // Check if the context deadline has been reached or the search is satisfied
+				wg.Wait()
+				if isSatisfied() {
+					skip(e.scrapers, append([][]int{tier[n:]}, tiers[t+1:]...), ErrNotNeeded, emit)
+					return nil
 				}
+				e.config.Logger.Error("timeout reached before starting all scrapers", "query", product, "tier", t+1, "not_started", len(tier[n:]))
+				skip(e.scrapers, append([][]int{tier[n:]}, tiers[t+1:]...), ctx.Err(), emit)
+				return ctx.Err()
 			}
-		}(scraper)
+
+			wg.Add(1)
+			go func(idx int, s Scraper) {
+				defer wg.Done()
+				defer func() { <-semaphore }() // Release the spot in the semaphore when the goroutine completes
+
+				if done.Err() != nil && ctx.Err() == nil { // Satisfied while waiting for a spot
+					emit(idx, SourceReport{Website: s.Info(), Status: StatusSkipped, Err: ErrNotNeeded}, nil)
+					return
+				}
+
+				report, products := e.scrape(done, s, product)
+				products = e.filter(done, product, report.Website, products)
+
+				completedMutex.Lock()
+				completed.add(products)
+				completedMutex.Unlock()
+
+				emit(idx, report, products)
+			}(idx, scraper)
+		}
+
+		wg.Wait() // Wait for the whole tier to finish
+	}
+
+	return nil
+}
+
+// skip reports every scraper of tiers as skipped because of err
+func skip(scrapers []Scraper, tiers [][]int, err error, emit func(idx int, report SourceReport, products []Product)) {
+	for _, tier := range tiers {
+		for _, idx := range tier {
+			emit(idx, SourceReport{Website: scrapers[idx].Info(), Status: StatusSkipped, Err: err}, nil)
+		}
+	}
+}
+
+// scrape runs a single scraper and reports how it ended
+func (e *Engine) scrape(ctx context.Context, s Scraper, product string) (SourceReport, []Product) {
+	report := SourceReport{Website: s.Info()}
+
+	if !report.Website.Supports(QueryTypeOf(product)) { // Only fan out to capable websites
+		report.Status, report.Err = StatusSkipped, ErrUnsupportedQuery
+		return report, nil
+	}
+
+	if err := ctx.Err(); err != nil { // Avoid executing if already timed out
+		report.Status, report.Err = StatusSkipped, err
+		return report, nil
+	}
+
+	if entry, ok := e.cached(ctx, report.Website, product); ok {
+		report.Cached, report.CachedAt = true, entry.CachedAt
+		if entry.NotFound {
+			report.Status, report.Err = StatusNotFound, fmt.Errorf("scraper %s failed: %w", report.Website, ErrProductNotFound)
+			return report, nil
+		}
+		report.Status, report.Count = StatusOK, len(entry.Products)
+		return report, entry.Products
+	}
+
+	breaker := e.breaker(report.Website)
+	if breaker != nil && !breaker.allow() {
+		e.debug("circuit open, skipping", "scraper", report.Website.key(), "query", product)
+		report.Status, report.Err = StatusSkipped, ErrCircuitOpen
+		return report, nil
 	}
 
-	wg.Wait() // Wait for all goroutines to finish
+	e.scraperStarted(ctx, ScraperStartedEvent{Query: product, Website: report.Website})
+	defer func() { e.scraperFinished(ctx, ScraperFinishedEvent{Query: product, Report: report}) }()
+
+	logger := &scopedLogger{logger: e.config.Logger, attrs: []any{"scraper", report.Website.key(), "query", product}, debug: e.config.Debug}
+	ctx = ContextWithLogger(ctx, logger)
+
+	start := time.Now()
+	answer, coalesced := e.call(ctx, s, report.Website, product, breaker)
+	products, err := answer.products, answer.err
+	report.Attempts, report.Coalesced = answer.attempts, coalesced
+	report.Latency = time.Since(start)
+	report.Count = len(products)
+	report.Status = statusOf(err)
+	if err != nil {
+		report.Err = fmt.Errorf("scraper %s failed: %w", report.Website, err)
+		attrs := []any{"status", report.Status, "attempts", report.Attempts, "duration_ms", report.Latency.Milliseconds(), "err", err}
+		if panicErr := (*PanicError)(nil); errors.As(err, &panicErr) {
+			attrs = append(attrs, "stack", string(panicErr.Stack))
+		}
+		if report.Status == StatusNotFound || report.Status == StatusCanceled {
+			logger.Debug("scraper finished", attrs...)
+		} else {
+			logger.Error("scraper failed", attrs...)
+		}
+		return report, nil
+	}
+	logger.Debug("scraper finished", "status", report.Status, "attempts", report.Attempts, "duration_ms", report.Latency.Milliseconds(), "count", report.Count)
+
+	return report, products
+}
+
+// answer is the outcome of querying a scraper, shared by coalesced callers
+type answer struct {
+	products []Product
+	attempts int
+	err      error
+}
 
-	if isGTIN(product) {
-		filteredResults := make([]Product, 0)
-		for _, result := range results {
-			if result.GTIN == product {
-				filteredResults = append(filteredResults, result)
+// call queries the scraper for product, or joins an identical query already
+// in flight when coalescing is enabled. The second result reports whether the
+// answer was shared.
+func (e *Engine) call(ctx context.Context, s Scraper, website Website, product string, breaker *breaker) (answer, bool) {
+	fetch := func(ctx context.Context) (answer, error) {
+		report := SourceReport{Website: website}
+		products, err := e.attempts(ctx, s, &report, product)
+		if breaker != nil {
+			breaker.record(err)
+		}
+		if err != nil {
+			products = nil
+		}
+
+		for i := range products {
+			if products[i].Source.URL == "" {
+				products[i].Source = website
+			}
+			for j := range products[i].Offers {
+				if products[i].Offers[j].Store == "" {
+					products[i].Offers[j].Store = products[i].Source.String()
+				}
 			}
 		}
-		results = filteredResults
+		e.store(website, product, products, err)
+
+		return answer{products: products, attempts: report.Attempts, err: err}, nil
 	}
 
-	return results, nil
+	if !e.config.Coalesce {
+		a, _ := fetch(ctx)
+		return a, false
+	}
+
+	a, shared, err := e.inflight.do(ctx, CacheKey(website, product), e.config.Timeout, fetch)
+	if err != nil { // This caller gave up before the shared answer came
+		return answer{err: err}, shared
+	}
+	a.products = cloneProducts(a.products) // Each caller may modify its products
+	return a, shared
 }
 
+// AddScraper registers s, wrapped in the middlewares of the Engine. It must
+// not be called while searching.
 func (e *Engine) AddScraper(s Scraper) {
-	e.scrapers = append(e.scrapers, s)
+	e.scrapers = append(e.scrapers, e.wrap(s))
 }
 
-func (e *Engine) debug(args ...any) {
+func (e *Engine) debug(msg string, args ...any) {
 	if e.config.Debug {
-		e.config.Logger.Debug(args...)
+		e.config.Logger.Debug(msg, args...)
 	}
 }
 
-func isGTIN(s string) bool {
-	return gtinRegex.MatchString(s)
+// queryForms returns the queries to send to website for product. Barcodes are
+// expanded to the representations listed in the website GTINFormats.
+func queryForms(website Website, product string) []string {
+	g, err := gtin.Parse(product)
+	if err != nil || len(website.GTINFormats) == 0 {
+		return []string{product}
+	}
+
+	forms := make([]string, 0, len(website.GTINFormats))
+	for _, format := range website.GTINFormats {
+		if form, ok := g.As(format); ok && !slices.Contains(forms, form) {
+			forms = append(forms, form)
+		}
+	}
+	if len(forms) == 0 {
+		return []string{product}
+	}
+	return forms
+}
+
+// filterByGTIN keeps only the products matching product when it is a GTIN.
+// Codes are compared in their normalized form, so 07891234567895 matches
+// 7891234567895, and matching products are relabeled with the canonical GTIN.
+func filterByGTIN(product string, products []Product) []Product {
+	want, err := gtin.Parse(product)
+	if err != nil {
+		return products
+	}
+
+	filteredResults := make([]Product, 0, len(products))
+	for _, result := range products {
+		if got, err := gtin.Parse(result.GTIN); err == nil && got == want {
+			result.GTIN = want.String()
+			filteredResults = append(filteredResults, result)
+		}
+	}
+	return filteredResults
 }
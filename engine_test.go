@@ -0,0 +1,221 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	core "achapromo.com/productscout"
+	"achapromo.com/productscout/gtin"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeScraper struct {
+	url        string
+	queryTypes []core.QueryType
+	delay      time.Duration
+	products   []core.Product
+	err        error
+}
+
+func (f *fakeScraper) Scrape(ctx context.Context, query string) ([]core.Product, error) {
+	select {
+	case <-time.After(f.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return f.products, f.err
+}
+
+func (f *fakeScraper) Info() core.Website {
+	return core.Website{URL: f.url, QueryTypes: f.queryTypes}
+}
+
+// discardHandler silences the logs of engines whose failures are expected
+func discardHandler() slog.Handler {
+	return slog.NewTextHandler(io.Discard, nil)
+}
+
+type fakeLegacyScraper struct {
+	delay time.Duration
+}
+
+func (f *fakeLegacyScraper) Scrape(product string) ([]core.Product, error) {
+	time.Sleep(f.delay)
+	return []core.Product{{Name: "Legacy", GTIN: product}}, nil
+}
+
+func (f *fakeLegacyScraper) Info() core.Website {
+	return core.Website{URL: "https://legacy.example"}
+}
+
+func TestEngine_Search_TimeoutCancelsScrapers(t *testing.T) {
+	engine := core.NewEngine(
+		core.WithTimeout(50*time.Millisecond),
+		core.WithScrapers(
+			&fakeScraper{url: "https://fast.example", products: []core.Product{{Name: "Fast", GTIN: "7898215151784"}}},
+			&fakeScraper{url: "https://slow.example", delay: time.Minute},
+		),
+	)
+
+	start := time.Now()
+	products, err := engine.Search("7898215151784")
+
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Len(t, products, 1)
+}
+
+func TestEngine_Search_LegacyScraper(t *testing.T) {
+	engine := core.NewEngine(core.WithScrapers(core.FromLegacy(&fakeLegacyScraper{})))
+
+	products, err := engine.Search("7898215151784")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []core.Product{{Name: "Legacy", GTIN: "7898215151784", Source: core.Website{URL: "https://legacy.example"}}}, products)
+}
+
+func TestFromLegacy_ReturnsOnCancel(t *testing.T) {
+	scraper := core.FromLegacy(&fakeLegacyScraper{delay: time.Minute})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	products, err := scraper.Scrape(ctx, "7898215151784")
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Nil(t, products)
+}
+
+func TestEngine_SearchWithReport_SourceStatuses(t *testing.T) {
+	engine := core.NewEngine(
+		core.WithTimeout(50*time.Millisecond),
+		core.WithScrapers(
+			&fakeScraper{url: "https://ok.example", products: []core.Product{{Name: "Ok", GTIN: "7898215151784"}}},
+			&fakeScraper{url: "https://missing.example", err: core.ErrProductNotFound},
+			&fakeScraper{url: "https://broken.example", err: errors.New("boom")},
+			&fakeScraper{url: "https://slow.example", delay: time.Minute},
+		),
+	)
+
+	result, err := engine.SearchWithReport(context.Background(), "7898215151784")
+
+	assert.NoError(t, err)
+	assert.Len(t, result.Products, 1)
+	if !assert.Len(t, result.Sources, 4) {
+		return
+	}
+	assert.Equal(t, core.StatusOK, result.Sources[0].Status)
+	assert.Equal(t, 1, result.Sources[0].Count)
+	assert.Equal(t, core.StatusNotFound, result.Sources[1].Status)
+	assert.ErrorIs(t, result.Sources[1].Err, core.ErrProductNotFound)
+	assert.Equal(t, core.StatusError, result.Sources[2].Status)
+	assert.Equal(t, core.StatusTimedOut, result.Sources[3].Status)
+	assert.Equal(t, "https://slow.example", result.Sources[3].Website.URL)
+	assert.False(t, result.Failed())
+}
+
+func TestEngine_Stream_EmitsAsScrapersAnswer(t *testing.T) {
+	engine := core.NewEngine(core.WithScrapers(
+		&fakeScraper{url: "https://slow.example", delay: 100 * time.Millisecond, products: []core.Product{{Name: "Slow", GTIN: "7898215151784"}}},
+		&fakeScraper{url: "https://fast.example", products: []core.Product{{Name: "Fast", GTIN: "7898215151784"}}},
+	))
+
+	var events []core.SearchEvent
+	for event := range engine.Stream(context.Background(), "7898215151784") {
+		events = append(events, event)
+	}
+
+	if !assert.Len(t, events, 3) {
+		return
+	}
+	assert.Equal(t, "https://fast.example", events[0].Source.Website.URL)
+	assert.Equal(t, "Fast", events[0].Products[0].Name)
+	assert.Equal(t, "https://slow.example", events[1].Source.Website.URL)
+	assert.True(t, events[2].Done)
+	assert.NoError(t, events[2].Err)
+	assert.Len(t, events[2].Result.Products, 2)
+	assert.Equal(t, "https://slow.example", events[2].Result.Sources[0].Website.URL)
+}
+
+func TestEngine_Stream_MissingScraper(t *testing.T) {
+	event := <-core.NewEngine().Stream(context.Background(), "7898215151784")
+
+	assert.True(t, event.Done)
+	assert.ErrorIs(t, event.Err, core.ErrMissingScraper)
+}
+
+func TestEngine_Search_FiltersByNormalizedGTIN(t *testing.T) {
+	engine := core.NewEngine(core.WithScrapers(
+		&fakeScraper{url: "https://a.example", products: []core.Product{
+			{Name: "Padded", GTIN: "07898215151784"},
+			{Name: "Other", GTIN: "7898422745523"},
+		}},
+		&fakeScraper{url: "https://b.example", products: []core.Product{{Name: "Plain", GTIN: "7898215151784"}}},
+	))
+
+	products, err := engine.Search("7898215151784")
+
+	assert.NoError(t, err)
+	if assert.Len(t, products, 2) {
+		assert.Equal(t, "Padded", products[0].Name)
+		assert.Equal(t, "Plain", products[1].Name)
+	}
+}
+
+type indexedScraper struct {
+	formats  []gtin.Format
+	products map[string]core.Product
+	queries  []string
+}
+
+func (i *indexedScraper) Scrape(ctx context.Context, query string) ([]core.Product, error) {
+	i.queries = append(i.queries, query)
+	if product, ok := i.products[query]; ok {
+		return []core.Product{product}, nil
+	}
+	return nil, core.ErrProductNotFound
+}
+
+func (i *indexedScraper) Info() core.Website {
+	return core.Website{URL: "https://indexed.example", GTINFormats: i.formats}
+}
+
+func TestEngine_Search_ExpandsGTINForms(t *testing.T) {
+	scraper := &indexedScraper{
+		formats:  []gtin.Format{gtin.EAN13, gtin.UPCA},
+		products: map[string]core.Product{"036000291452": {Name: "Imported", GTIN: "036000291452"}},
+	}
+	engine := core.NewEngine(core.WithScrapers(scraper))
+
+	products, err := engine.Search("00036000291452")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0036000291452", "036000291452"}, scraper.queries)
+	if assert.Len(t, products, 1) {
+		assert.Equal(t, "0036000291452", products[0].GTIN)
+	}
+}
+
+func TestEngine_SearchWithReport_TextQuery(t *testing.T) {
+	engine := core.NewEngine(core.WithScrapers(
+		&fakeScraper{url: "https://barcode.example", products: []core.Product{{Name: "Unexpected"}}},
+		&fakeScraper{url: "https://text.example", queryTypes: []core.QueryType{core.QueryGTIN, core.QueryText}, products: []core.Product{
+			{Name: "Leite Condensado Piracanjuba 395g"},
+			{Name: "Creme de Leite Piracanjuba 200g"},
+			{Name: "Creme de Leite Nestlé 200g"},
+		}},
+	))
+
+	result, err := engine.SearchWithReport(context.Background(), "creme de leite piracanjuba")
+
+	assert.NoError(t, err)
+	assert.Equal(t, core.StatusSkipped, result.Sources[0].Status)
+	assert.ErrorIs(t, result.Sources[0].Err, core.ErrUnsupportedQuery)
+	if assert.Len(t, result.Products, 3) {
+		assert.Equal(t, "Creme de Leite Piracanjuba 200g", result.Products[0].Name)
+		assert.Equal(t, "Leite Condensado Piracanjuba 395g", result.Products[2].Name)
+	}
+}
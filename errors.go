@@ -0,0 +1,76 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"achapromo.com/productscout/httpclient"
+)
+
+// Failure classes, produced by the HttpClient and the scrapers. Match them
+// with errors.Is on a SourceReport Err or on SearchResult.Err; the status code
+// is available through errors.As with an *httpclient.StatusError.
+var (
+	ErrRateLimited         = httpclient.ErrRateLimited
+	ErrBlocked             = httpclient.ErrBlocked
+	ErrUnauthorized        = httpclient.ErrUnauthorized
+	ErrSchemaChanged       = httpclient.ErrSchemaChanged
+	ErrUpstreamUnavailable = httpclient.ErrUpstreamUnavailable
+)
+
+// RetryAfter returns the delay a rate limited source asked for, if err
+// carries one
+func RetryAfter(err error) (time.Duration, bool) {
+	return httpclient.RetryAfter(err)
+}
+
+// Err joins the errors of the sources that failed, so errors.Is and errors.As
+// match any of them. Not found answers and skipped sources are not failures.
+// It returns nil when no source failed.
+func (r *SearchResult) Err() error {
+	var errs []error
+	for _, source := range r.Sources {
+		if source.Status == StatusError || source.Status == StatusTimedOut || source.Status == StatusPanicked {
+			errs = append(errs, source.Err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// failure explains a search where no source answered by joining the errors
+// of every source, skipped ones included. It returns nil when a source
+// answered.
+func (r *SearchResult) failure() error {
+	if !r.Failed() {
+		return nil
+	}
+	errs := make([]error, len(r.Sources))
+	for i, source := range r.Sources {
+		errs[i] = source.Err
+	}
+	return errors.Join(errs...)
+}
+
+// errorClass names the kind of failure of err, for metrics
+func errorClass(err error) string {
+	switch {
+	case errors.As(err, new(*PanicError)):
+		return "panic"
+	case errors.Is(err, ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, ErrBlocked):
+		return "blocked"
+	case errors.Is(err, ErrUnauthorized):
+		return "unauthorized"
+	case errors.Is(err, ErrSchemaChanged):
+		return "schema_changed"
+	case errors.Is(err, ErrUpstreamUnavailable):
+		return "upstream_unavailable"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+	return "other"
+}
@@ -0,0 +1,122 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	core "achapromo.com/productscout"
+	"achapromo.com/productscout/httpclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHttpClient_ErrorClasses(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   error
+	}{
+		{status: http.StatusTooManyRequests, want: core.ErrRateLimited},
+		{status: http.StatusForbidden, want: core.ErrBlocked},
+		{status: http.StatusUnauthorized, want: core.ErrUnauthorized},
+		{status: http.StatusBadGateway, want: core.ErrUpstreamUnavailable},
+		{status: http.StatusOK, body: `{"products": []}`, want: core.ErrSchemaChanged},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "30")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := (&httpScraper{url: server.URL, client: httpclient.NewHttpClient()}).Scrape(context.Background(), "7898215151784")
+
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestHttpClient_NetworkFailure(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	_, err := (&httpScraper{url: server.URL, client: httpclient.NewHttpClient()}).Scrape(context.Background(), "7898215151784")
+
+	assert.ErrorIs(t, err, core.ErrUpstreamUnavailable)
+}
+
+func TestRetryAfter(t *testing.T) {
+	after, ok := core.RetryAfter(&httpclient.StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Minute})
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, after)
+
+	_, ok = core.RetryAfter(errors.New("boom"))
+	assert.False(t, ok)
+}
+
+func TestEngine_BlockedIsNotRetried(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	engine := core.NewEngine(
+		core.WithDefaultScraperPolicy(core.ScraperPolicy{Retries: 3}),
+		core.WithScrapers(&httpScraper{url: server.URL, client: httpclient.NewHttpClient()}),
+	)
+
+	result, err := engine.SearchWithReport(context.Background(), "7898215151784")
+
+	assert.ErrorIs(t, err, core.ErrBlocked)
+	assert.Equal(t, 1, calls)
+	assert.ErrorIs(t, result.Err(), core.ErrBlocked)
+	var statusErr *httpclient.StatusError
+	if assert.ErrorAs(t, result.Err(), &statusErr) {
+		assert.Equal(t, http.StatusForbidden, statusErr.StatusCode)
+	}
+}
+
+func TestSearchResult_Err(t *testing.T) {
+	engine := core.NewEngine(
+		core.WithTimeout(50*time.Millisecond),
+		core.WithScrapers(
+			&fakeScraper{url: "https://ok.example", products: []core.Product{{Name: "Ok", GTIN: "7898215151784"}}},
+			&fakeScraper{url: "https://missing.example", err: core.ErrProductNotFound},
+			&fakeScraper{url: "https://limited.example", err: core.ErrRateLimited},
+			&fakeScraper{url: "https://slow.example", delay: time.Minute},
+		),
+	)
+
+	result, err := engine.SearchWithReport(context.Background(), "7898215151784")
+
+	assert.NoError(t, err)
+	assert.ErrorIs(t, result.Err(), core.ErrRateLimited)
+	assert.ErrorIs(t, result.Err(), context.DeadlineExceeded)
+	assert.NotErrorIs(t, result.Err(), core.ErrProductNotFound)
+
+	assert.NoError(t, (&core.SearchResult{Sources: result.Sources[:2]}).Err())
+}
+
+func TestEngine_Search_EverySourceFailed(t *testing.T) {
+	engine := core.NewEngine(
+		core.WithLogger(core.NewLogger(discardHandler())),
+		core.WithScrapers(
+			&fakeScraper{url: "https://limited.example", err: core.ErrRateLimited},
+			&fakeScraper{url: "https://text.example", queryTypes: []core.QueryType{core.QueryText}},
+		),
+	)
+
+	products, err := engine.Search("7898215151784")
+
+	assert.Empty(t, products)
+	assert.ErrorIs(t, err, core.ErrRateLimited)
+	assert.ErrorIs(t, err, core.ErrUnsupportedQuery, "skipped sources tell why they did not answer")
+}
@@ -2,33 +2,39 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"time"
 
 	core "achapromo.com/productscout"
-	"achapromo.com/productscout/httpclient"
-	barcodemonster "achapromo.com/productscout/websites/barcode.monster"
-	comprafoodservicecombr "achapromo.com/productscout/websites/comprafoodservice.com.br"
-	openfoodfactsorg "achapromo.com/productscout/websites/openfoodfacts.org"
-	paguemenoscombr "achapromo.com/productscout/websites/paguemenos.com.br"
+	_ "achapromo.com/productscout/websites/all"
 )
 
+// Usage: go run ./example [config.yaml|config.json]
 func main() {
 
-	httpClient := httpclient.NewHttpClient(
-		httpclient.WithUserAgent("gtinscout"),
-		httpclient.WithTimeout(time.Second*30),
-	)
-
-	engine := core.NewEngine(
-		core.WithDebug(),
-		core.WithMaxConcurrency(20),
-		core.WithScrapers(
-			&comprafoodservicecombr.Scraper{HttpClient: httpClient},
-			&barcodemonster.Scraper{HttpClient: httpClient},
-			&openfoodfactsorg.Scraper{HttpClient: httpClient},
-			&paguemenoscombr.Scraper{HttpClient: httpClient},
-		),
-	)
+	cfg := &core.ConfigFile{Debug: true, MaxConcurrency: 20}
+	for _, id := range core.Registered() {
+		cfg.Scrapers = append(cfg.Scrapers, core.ScraperConfig{
+			ID:       id,
+			Settings: core.Settings{UserAgent: "gtinscout", Timeout: core.Duration(time.Second * 30)},
+		})
+	}
+
+	if len(os.Args) > 1 {
+		var err error
+		if cfg, err = core.LoadConfigFile(os.Args[1]); err != nil {
+			panic(err)
+		}
+	}
+
+	engine, err := core.NewEngineFromConfig(*cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	if err := core.WriteCatalog(os.Stdout, engine.Sources()); err != nil {
+		panic(err)
+	}
 
 	products, err := engine.Search("7895800430002")
 	if err != nil {
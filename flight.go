@@ -0,0 +1,123 @@
+package core
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+type (
+	// flightGroup coalesces concurrent calls sharing a key into a single call
+	// of fn, whose result every caller gets. The call runs on a context
+	// detached from the callers, bound by its own timeout, and is cancelled
+	// once every caller gave up.
+	flightGroup[T any] struct {
+		mu    sync.Mutex
+		calls map[string]*flightCall[T]
+	}
+
+	flightCall[T any] struct {
+		done   chan struct{}
+		value  T
+		err    error
+		refs   int
+		cancel context.CancelFunc
+	}
+)
+
+// do runs fn once for all the concurrent callers of key. shared reports
+// whether the caller joined a call started by another one. A caller whose ctx
+// ends first gets ctx.Err() without waiting for fn. fn ends after timeout, when
+// positive, whatever the deadlines of the callers.
+func (g *flightGroup[T]) do(ctx context.Context, key string, timeout time.Duration, fn func(ctx context.Context) (T, error)) (value T, shared bool, err error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = map[string]*flightCall[T]{}
+	}
+	call, shared := g.calls[key]
+	if !shared {
+		var callCtx context.Context
+		var cancel context.CancelFunc
+		if timeout > 0 {
+			callCtx, cancel = context.WithTimeout(context.WithoutCancel(ctx), timeout)
+		} else {
+			callCtx, cancel = context.WithCancel(context.WithoutCancel(ctx))
+		}
+		call = &flightCall[T]{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = call
+
+		go func() {
+			defer close(call.done)
+			defer cancel()
+			call.value, call.err = fn(callCtx)
+			g.forget(key, call)
+		}()
+	}
+	call.refs++
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.value, shared, call.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		call.refs--
+		if call.refs == 0 { // Nobody waits for the answer anymore
+			call.cancel()
+			g.forgetLocked(key, call)
+		}
+		g.mu.Unlock()
+		return value, shared, ctx.Err()
+	}
+}
+
+func (g *flightGroup[T]) forget(key string, call *flightCall[T]) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.forgetLocked(key, call)
+}
+
+// forgetLocked removes call, unless a newer call already replaced it
+func (g *flightGroup[T]) forgetLocked(key string, call *flightCall[T]) {
+	if g.calls[key] == call {
+		delete(g.calls, key)
+	}
+}
+
+// WithCoalescing makes concurrent identical queries share their upstream
+// requests. Searches for the same query share one run of SearchWithReport,
+// and calls to the same scraper for the same query, from any kind of search,
+// share one call. A shared call is bound by the Engine timeout, not by the
+// deadlines of its callers, and is cancelled once every caller gave up. Search
+// hooks fire once per shared search, while scraper hooks fire for every
+// caller, with Coalesced set in the report of the callers that joined another
+// call.
+func WithCoalescing() Option {
+	return func(e *Engine) {
+		e.config.Coalesce = true
+	}
+}
+
+// coalescedSearch runs search for product, or joins an identical search in
+// flight
+func (e *Engine) coalescedSearch(ctx context.Context, product string) (*SearchResult, error) {
+	result, _, err := e.searches.do(ctx, canonicalQuery(product), e.config.Timeout, func(ctx context.Context) (*SearchResult, error) {
+		return e.search(ctx, product)
+	})
+
+	if result == nil { // This caller gave up before the shared result came
+		sources := make([]SourceReport, len(e.scrapers))
+		for i, scraper := range e.scrapers {
+			sources[i] = SourceReport{Website: scraper.Info(), Status: statusOf(err), Err: err}
+		}
+		return &SearchResult{Query: product, Products: []Product{}, Sources: sources}, err
+	}
+
+	// Each caller may modify its result
+	return &SearchResult{
+		Query:    product,
+		Products: cloneProducts(result.Products),
+		Sources:  slices.Clone(result.Sources),
+	}, err
+}
@@ -0,0 +1,200 @@
+package core_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	core "achapromo.com/productscout"
+	"github.com/stretchr/testify/assert"
+)
+
+// gateScraper answers once released, counting its calls and cancellations
+type gateScraper struct {
+	url      string
+	release  chan struct{}
+	calls    atomic.Int32
+	canceled atomic.Int32
+}
+
+func (g *gateScraper) Scrape(ctx context.Context, query string) ([]core.Product, error) {
+	g.calls.Add(1)
+	select {
+	case <-g.release:
+		return []core.Product{{Name: "Shared", GTIN: query}}, nil
+	case <-ctx.Done():
+		g.canceled.Add(1)
+		return nil, ctx.Err()
+	}
+}
+
+func (g *gateScraper) Info() core.Website {
+	return core.Website{URL: g.url}
+}
+
+// watchedContext tells when a caller first waits on it, which a coalesced
+// caller does once it joined the shared call
+type watchedContext struct {
+	context.Context
+	once    sync.Once
+	waiting chan struct{}
+}
+
+func watch(ctx context.Context) *watchedContext {
+	return &watchedContext{Context: ctx, waiting: make(chan struct{})}
+}
+
+func (w *watchedContext) Done() <-chan struct{} {
+	w.once.Do(func() { close(w.waiting) })
+	return w.Context.Done()
+}
+
+func TestEngine_Coalescing_Search(t *testing.T) {
+	scraper := &gateScraper{url: "https://gate.example", release: make(chan struct{})}
+	engine := core.NewEngine(core.WithCoalescing(), core.WithScrapers(scraper))
+
+	results := make([]*core.SearchResult, 5)
+	var wg sync.WaitGroup
+	for i := range results {
+		ctx := watch(context.Background())
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = engine.SearchWithReport(ctx, "7898215151784")
+		}(i)
+		<-ctx.waiting
+	}
+	close(scraper.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), scraper.calls.Load())
+	for _, result := range results {
+		if assert.Len(t, result.Products, 1) {
+			assert.Equal(t, "Shared", result.Products[0].Name)
+		}
+	}
+
+	results[0].Products[0].Name = "Modified"
+	assert.Equal(t, "Shared", results[1].Products[0].Name, "each caller gets its own result")
+}
+
+func TestEngine_Coalescing_Scraper(t *testing.T) {
+	scraper := &gateScraper{url: "https://gate.example", release: make(chan struct{})}
+	engine := core.NewEngine(core.WithCoalescing(), core.WithScrapers(scraper))
+
+	var search *core.SearchResult
+	var batch map[string]*core.SearchResult
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		search, _ = engine.SearchWithReport(context.Background(), "7898215151784")
+	}()
+	assert.Eventually(t, func() bool { return scraper.calls.Load() == 1 }, time.Second, time.Millisecond)
+	ctx := watch(context.Background())
+	go func() {
+		defer wg.Done()
+		batch, _ = engine.SearchBatch(ctx, []string{"07898215151784"})
+	}()
+	<-ctx.waiting // The batch joined the call of the search
+	close(scraper.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), scraper.calls.Load())
+	assert.Len(t, search.Products, 1)
+	assert.Len(t, batch["07898215151784"].Products, 1)
+	assert.False(t, search.Sources[0].Coalesced)
+	assert.True(t, batch["07898215151784"].Sources[0].Coalesced)
+}
+
+func TestEngine_Coalescing_Cancellation(t *testing.T) {
+	scraper := &gateScraper{url: "https://gate.example", release: make(chan struct{})}
+	engine := core.NewEngine(core.WithCoalescing(), core.WithScrapers(scraper))
+
+	cancellable, leave := context.WithCancel(context.Background())
+	leaving := watch(cancellable)
+	left := make(chan error, 1)
+	go func() {
+		_, err := engine.SearchWithReport(leaving, "7898215151784")
+		left <- err
+	}()
+
+	<-leaving.waiting
+	staying := watch(context.Background())
+	stayed := make(chan *core.SearchResult, 1)
+	go func() {
+		result, _ := engine.SearchWithReport(staying, "7898215151784")
+		stayed <- result
+	}()
+	<-staying.waiting
+
+	leave()
+	assert.ErrorIs(t, <-left, context.Canceled)
+	assert.Equal(t, int32(0), scraper.canceled.Load(), "the call goes on for the remaining caller")
+
+	close(scraper.release)
+	result := <-stayed
+	assert.Len(t, result.Products, 1)
+	assert.Equal(t, int32(1), scraper.calls.Load())
+}
+
+func TestEngine_Coalescing_LeaderDeadline(t *testing.T) {
+	scraper := &gateScraper{url: "https://gate.example", release: make(chan struct{})}
+	engine := core.NewEngine(core.WithCoalescing(), core.WithScrapers(scraper))
+
+	short, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	leader := watch(short)
+	led := make(chan error, 1)
+	go func() {
+		_, err := engine.SearchWithReport(leader, "7898215151784")
+		led <- err
+	}()
+
+	<-leader.waiting
+	joiner := watch(context.Background())
+	joined := make(chan *core.SearchResult, 1)
+	go func() {
+		result, _ := engine.SearchWithReport(joiner, "7898215151784")
+		joined <- result
+	}()
+	<-joiner.waiting
+
+	assert.ErrorIs(t, <-led, context.DeadlineExceeded)
+	assert.Never(t, func() bool { return scraper.canceled.Load() > 0 }, 50*time.Millisecond, time.Millisecond,
+		"the leader deadline does not bind the shared call")
+	close(scraper.release)
+	result := <-joined
+	if assert.Len(t, result.Products, 1) {
+		assert.Equal(t, "Shared", result.Products[0].Name)
+	}
+	assert.Equal(t, core.StatusOK, result.Sources[0].Status)
+}
+
+func TestEngine_Coalescing_EngineTimeout(t *testing.T) {
+	scraper := &gateScraper{url: "https://gate.example", release: make(chan struct{})}
+	engine := core.NewEngine(core.WithCoalescing(), core.WithTimeout(20*time.Millisecond), core.WithScrapers(scraper))
+
+	result, _ := engine.SearchWithReport(context.Background(), "7898215151784")
+	assert.Equal(t, core.StatusTimedOut, result.Sources[0].Status)
+	assert.Eventually(t, func() bool { return scraper.canceled.Load() == 1 }, time.Second, time.Millisecond)
+}
+
+func TestEngine_Coalescing_CancelledOnceEveryoneLeft(t *testing.T) {
+	scraper := &gateScraper{url: "https://gate.example", release: make(chan struct{})}
+	engine := core.NewEngine(core.WithCoalescing(), core.WithScrapers(scraper))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		engine.SearchWithReport(ctx, "7898215151784")
+	}()
+	assert.Eventually(t, func() bool { return scraper.calls.Load() == 1 }, time.Second, time.Millisecond)
+	cancel()
+	<-done
+
+	assert.Eventually(t, func() bool { return scraper.canceled.Load() == 1 }, time.Second, time.Millisecond)
+}
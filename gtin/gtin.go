@@ -0,0 +1,154 @@
+// Package gtin parses and validates Global Trade Item Numbers of every
+// length: GTIN-8 (EAN-8), GTIN-12 (UPC-A), GTIN-13 (EAN-13) and GTIN-14.
+package gtin
+
+import (
+	"errors"
+	"strings"
+)
+
+// Format is a GTIN representation, named after its number of digits
+type Format int
+
+const (
+	GTIN8  Format = 8
+	GTIN12 Format = 12
+	GTIN13 Format = 13
+	GTIN14 Format = 14
+
+	UPCA  = GTIN12
+	EAN8  = GTIN8
+	EAN13 = GTIN13
+)
+
+var (
+	ErrInvalidLength     = errors.New("gtin: invalid length")
+	ErrInvalidCharacter  = errors.New("gtin: invalid character")
+	ErrInvalidCheckDigit = errors.New("gtin: invalid check digit")
+)
+
+// GTIN is a validated code, stored right-aligned in its 14-digit form as GS1
+// specifies, so equal products compare equal whatever length they came in.
+// Build it with Parse: values not holding 14 digits, like the zero value, fit
+// in no format and have no variants.
+type GTIN string
+
+// Parse validates s as a GTIN of any supported length and returns it in its
+// 14-digit form. Surrounding whitespace is ignored.
+func Parse(s string) (GTIN, error) {
+	s = strings.TrimSpace(s)
+
+	switch Format(len(s)) {
+	case GTIN8, GTIN12, GTIN13, GTIN14:
+	default:
+		return "", ErrInvalidLength
+	}
+
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return "", ErrInvalidCharacter
+		}
+	}
+
+	check, _ := CheckDigit(s[:len(s)-1])
+	if s[len(s)-1] != check {
+		return "", ErrInvalidCheckDigit
+	}
+
+	return GTIN(pad(s, GTIN14)), nil
+}
+
+// Valid reports whether s is a GTIN of any supported length with a correct
+// check digit
+func Valid(s string) bool {
+	_, err := Parse(s)
+	return err == nil
+}
+
+// Normalize parses s and returns its canonical form
+func Normalize(s string) (string, error) {
+	g, err := Parse(s)
+	if err != nil {
+		return "", err
+	}
+	return g.String(), nil
+}
+
+// CheckDigit computes the GS1 mod-10 check digit for payload, the GTIN
+// without its last digit
+func CheckDigit(payload string) (byte, error) {
+	sum := 0
+	for i := len(payload) - 1; i >= 0; i-- {
+		c := payload[i]
+		if c < '0' || c > '9' {
+			return 0, ErrInvalidCharacter
+		}
+
+		weight := 1
+		if (len(payload)-1-i)%2 == 0 { // The digit next to the check digit weighs 3
+			weight = 3
+		}
+		sum += int(c-'0') * weight
+	}
+
+	return byte('0' + (10-sum%10)%10), nil
+}
+
+// String returns the canonical form: the 13-digit EAN-13 when the code has a
+// leading zero, and the full GTIN-14 otherwise
+func (g GTIN) String() string {
+	if s, ok := g.As(GTIN13); ok {
+		return s
+	}
+	return string(g)
+}
+
+// GTIN14 returns the zero-padded 14-digit form
+func (g GTIN) GTIN14() string {
+	return string(g)
+}
+
+// As returns the code in the given format. It reports false when the code
+// does not fit in it, that is when the digits it would drop are not zeros.
+func (g GTIN) As(f Format) (string, bool) {
+	switch f {
+	case GTIN8, GTIN12, GTIN13, GTIN14:
+	default:
+		return "", false
+	}
+	if len(g) != int(GTIN14) { // Not built by Parse
+		return "", false
+	}
+
+	drop := len(g) - int(f)
+	if strings.Trim(string(g[:drop]), "0") != "" {
+		return "", false
+	}
+	return string(g[drop:]), true
+}
+
+// Variants returns every representation of the code, from the shortest format
+// it fits in up to the zero-padded GTIN-14
+func (g GTIN) Variants() []string {
+	variants := []string{}
+	for _, f := range []Format{GTIN8, GTIN12, GTIN13, GTIN14} {
+		if s, ok := g.As(f); ok {
+			variants = append(variants, s)
+		}
+	}
+	return variants
+}
+
+// Format returns the shortest format the code fits in
+func (g GTIN) Format() Format {
+	for _, f := range []Format{GTIN8, GTIN12, GTIN13} {
+		if _, ok := g.As(f); ok {
+			return f
+		}
+	}
+	return GTIN14
+}
+
+func pad(s string, f Format) string {
+	return strings.Repeat("0", int(f)-len(s)) + s
+}
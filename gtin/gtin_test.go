@@ -0,0 +1,91 @@
+package gtin_test
+
+import (
+	"testing"
+
+	"achapromo.com/productscout/gtin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input     string
+		canonical string
+		format    gtin.Format
+		err       error
+	}{
+		{input: "96385074", canonical: "0000096385074", format: gtin.GTIN8},
+		{input: "036000291452", canonical: "0036000291452", format: gtin.GTIN12},
+		{input: "7898215151784", canonical: "7898215151784", format: gtin.GTIN13},
+		{input: "07898215151784", canonical: "7898215151784", format: gtin.GTIN13},
+		{input: "17898215151781", canonical: "17898215151781", format: gtin.GTIN14},
+		{input: " 7898422745523 ", canonical: "7898422745523", format: gtin.GTIN13},
+		{input: "7898215151785", err: gtin.ErrInvalidCheckDigit},
+		{input: "789821515178", err: gtin.ErrInvalidCheckDigit},
+		{input: "78982151517", err: gtin.ErrInvalidLength},
+		{input: "78982151517a4", err: gtin.ErrInvalidCharacter},
+		{input: "", err: gtin.ErrInvalidLength},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			g, err := gtin.Parse(tt.input)
+
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				assert.False(t, gtin.Valid(tt.input))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.canonical, g.String())
+			assert.Equal(t, tt.format, g.Format())
+			assert.Len(t, g.GTIN14(), 14)
+		})
+	}
+}
+
+func TestGTIN_As(t *testing.T) {
+	g, err := gtin.Parse("036000291452")
+	assert.NoError(t, err)
+
+	upc, ok := g.As(gtin.UPCA)
+	assert.True(t, ok)
+	assert.Equal(t, "036000291452", upc)
+
+	ean, ok := g.As(gtin.EAN13)
+	assert.True(t, ok)
+	assert.Equal(t, "0036000291452", ean)
+
+	_, ok = g.As(gtin.GTIN8)
+	assert.False(t, ok)
+}
+
+func TestCheckDigit(t *testing.T) {
+	digit, err := gtin.CheckDigit("789821515178")
+
+	assert.NoError(t, err)
+	assert.Equal(t, byte('4'), digit)
+}
+
+func TestGTIN_Variants(t *testing.T) {
+	g, err := gtin.Parse("07891234567895")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"7891234567895", "07891234567895"}, g.Variants())
+
+	upc, err := gtin.Parse("036000291452")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"036000291452", "0036000291452", "00036000291452"}, upc.Variants())
+}
+
+func TestGTIN_ZeroAndMalformed(t *testing.T) {
+	for _, g := range []gtin.GTIN{"", "7898215151784", "123"} {
+		assert.NotPanics(t, func() {
+			_, ok := g.As(gtin.GTIN13)
+			assert.False(t, ok)
+			assert.Equal(t, string(g), g.String())
+			assert.Empty(t, g.Variants())
+		})
+	}
+}
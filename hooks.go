@@ -0,0 +1,124 @@
+package core
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// Hooks observe what the Engine does, for metrics, tracing or auditing.
+	// Every field is optional. Hooks run synchronously on the goroutines of the
+	// search, so they must be quick and safe for concurrent use.
+	Hooks struct {
+		SearchStarted   func(ctx context.Context, event SearchStartedEvent)
+		ScraperStarted  func(ctx context.Context, event ScraperStartedEvent)
+		ScraperFinished func(ctx context.Context, event ScraperFinishedEvent)
+		ResultFiltered  func(ctx context.Context, event ResultFilteredEvent)
+		SearchFinished  func(ctx context.Context, event SearchFinishedEvent)
+		CacheLookup     func(ctx context.Context, event CacheLookupEvent)
+	}
+
+	// SearchStartedEvent is emitted once per query, before any scraper runs
+	SearchStartedEvent struct {
+		Query    string
+		Scrapers int
+	}
+
+	// ScraperStartedEvent is emitted when a scraper is about to be called.
+	// Scrapers skipped by the Engine are never started.
+	ScraperStartedEvent struct {
+		Query   string
+		Website Website
+	}
+
+	// ScraperFinishedEvent is emitted after every started scraper. Report holds
+	// its duration, product count, attempts and error.
+	ScraperFinishedEvent struct {
+		Query  string
+		Report SourceReport
+	}
+
+	// ResultFilteredEvent is emitted when products of a scraper are dropped
+	// because their GTIN does not match the query
+	ResultFilteredEvent struct {
+		Query   string
+		Website Website
+		Kept    int
+		Dropped int
+	}
+
+	// SearchFinishedEvent is emitted once per query with its whole result. For
+	// batches, Duration covers the whole batch.
+	SearchFinishedEvent struct {
+		Query    string
+		Result   *SearchResult
+		Duration time.Duration
+		Err      error
+	}
+)
+
+// WithHooks registers hooks on the Engine. It may be used several times; the
+// hooks are called in the order they were registered.
+func WithHooks(h Hooks) Option {
+	return func(e *Engine) {
+		e.config.Hooks = append(e.config.Hooks, h)
+	}
+}
+
+func (e *Engine) searchStarted(ctx context.Context, event SearchStartedEvent) {
+	for _, h := range e.config.Hooks {
+		if h.SearchStarted != nil {
+			h.SearchStarted(ctx, event)
+		}
+	}
+}
+
+func (e *Engine) scraperStarted(ctx context.Context, event ScraperStartedEvent) {
+	for _, h := range e.config.Hooks {
+		if h.ScraperStarted != nil {
+			h.ScraperStarted(ctx, event)
+		}
+	}
+}
+
+func (e *Engine) scraperFinished(ctx context.Context, event ScraperFinishedEvent) {
+	for _, h := range e.config.Hooks {
+		if h.ScraperFinished != nil {
+			h.ScraperFinished(ctx, event)
+		}
+	}
+}
+
+func (e *Engine) resultFiltered(ctx context.Context, event ResultFilteredEvent) {
+	for _, h := range e.config.Hooks {
+		if h.ResultFiltered != nil {
+			h.ResultFiltered(ctx, event)
+		}
+	}
+}
+
+func (e *Engine) searchFinished(ctx context.Context, event SearchFinishedEvent) {
+	for _, h := range e.config.Hooks {
+		if h.SearchFinished != nil {
+			h.SearchFinished(ctx, event)
+		}
+	}
+}
+
+func (e *Engine) cacheLookup(ctx context.Context, event CacheLookupEvent) {
+	for _, h := range e.config.Hooks {
+		if h.CacheLookup != nil {
+			h.CacheLookup(ctx, event)
+		}
+	}
+}
+
+// filter keeps the products of website matching query and reports the ones
+// dropped
+func (e *Engine) filter(ctx context.Context, query string, website Website, products []Product) []Product {
+	kept := filterByGTIN(query, products)
+	if dropped := len(products) - len(kept); dropped > 0 {
+		e.resultFiltered(ctx, ResultFilteredEvent{Query: query, Website: website, Kept: len(kept), Dropped: dropped})
+	}
+	return kept
+}
@@ -0,0 +1,85 @@
+package core_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	core "achapromo.com/productscout"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder collects the events of an Engine as readable strings
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) hooks() core.Hooks {
+	return core.Hooks{
+		SearchStarted: func(ctx context.Context, e core.SearchStartedEvent) {
+			r.add("search started " + e.Query)
+		},
+		ScraperStarted: func(ctx context.Context, e core.ScraperStartedEvent) {
+			r.add("scraper started " + e.Website.String())
+		},
+		ScraperFinished: func(ctx context.Context, e core.ScraperFinishedEvent) {
+			r.add("scraper finished " + e.Report.Website.String() + " " + string(e.Report.Status))
+		},
+		ResultFiltered: func(ctx context.Context, e core.ResultFilteredEvent) {
+			r.add("filtered " + e.Website.String())
+		},
+		SearchFinished: func(ctx context.Context, e core.SearchFinishedEvent) {
+			r.add("search finished " + e.Query)
+		},
+	}
+}
+
+func TestEngine_Hooks(t *testing.T) {
+	recorded := &recorder{}
+	engine := core.NewEngine(
+		core.WithMaxConcurrency(1),
+		core.WithHooks(recorded.hooks()),
+		core.WithScrapers(
+			&fakeScraper{url: "https://a.example", products: []core.Product{
+				{Name: "Match", GTIN: "7898215151784"},
+				{Name: "Other", GTIN: "7898422745523"},
+			}},
+			&fakeScraper{url: "https://b.example", err: core.ErrProductNotFound},
+			&fakeScraper{url: "https://text.example", products: []core.Product{{Name: "Skipped"}}, queryTypes: []core.QueryType{core.QueryText}},
+		),
+	)
+
+	_, err := engine.Search("7898215151784")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"search started 7898215151784",
+		"scraper started https://a.example",
+		"scraper finished https://a.example ok",
+		"filtered https://a.example",
+		"scraper started https://b.example",
+		"scraper finished https://b.example not_found",
+		"search finished 7898215151784",
+	}, recorded.events)
+}
+
+func TestEngine_Hooks_Batch(t *testing.T) {
+	recorded := &recorder{}
+	engine := core.NewEngine(
+		core.WithHooks(recorded.hooks()),
+		core.WithScrapers(&countingScraper{url: "https://counting.example"}),
+	)
+
+	_, err := engine.SearchBatch(context.Background(), []string{"7898215151784", "7898422745523"})
+
+	assert.NoError(t, err)
+	assert.Len(t, recorded.events, 8)
+	assert.Contains(t, recorded.events, "search finished 7898422745523")
+}
@@ -0,0 +1,76 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrRateLimited reports a 429 answer. The StatusError carries the
+	// Retry-After delay when the server sent one.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrBlocked reports a 403 answer, usually a bot protection
+	ErrBlocked = errors.New("blocked")
+	// ErrUnauthorized reports a 401 answer, usually a missing or expired key
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrSchemaChanged reports an answer that no longer decodes into the
+	// expected structure
+	ErrSchemaChanged = errors.New("schema changed")
+	// ErrUpstreamUnavailable reports a 5xx answer or a network failure
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+)
+
+// StatusError reports an answer with an unexpected status code. It unwraps to
+// the error class of the code, if any.
+type StatusError struct {
+	StatusCode int
+	URL        string
+	// RetryAfter is the delay asked by the server, zero when it asked none
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code [%d] for the URL [%s]", e.StatusCode, e.URL)
+}
+
+func (e *StatusError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode == http.StatusForbidden:
+		return ErrBlocked
+	case e.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case e.StatusCode >= 500:
+		return ErrUpstreamUnavailable
+	}
+	return nil
+}
+
+// RetryAfter returns the delay the server asked for before retrying, if err
+// carries one
+func RetryAfter(err error) (time.Duration, bool) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		return statusErr.RetryAfter, true
+	}
+	return 0, false
+}
+
+// parseRetryAfter reads a Retry-After header, given either in seconds or as
+// an HTTP date
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
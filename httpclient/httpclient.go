@@ -1,11 +1,16 @@
 package httpclient
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
+
+	"achapromo.com/productscout/metrics"
 )
 
 // HttpClient struct holds any configuration and the http.Client instance
@@ -13,11 +18,38 @@ type HttpClient struct {
 	client    *http.Client
 	userAgent string
 	headers   map[string]string
+	requests  *metrics.Counter
+	durations *metrics.Histogram
+}
+
+// Logger is a structured logger, as implemented by *slog.Logger
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+type loggerKey struct{}
+
+// ContextWithLogger returns a copy of ctx carrying l. Requests bound to the
+// context are logged through l.
+func ContextWithLogger(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// LoggerFrom returns the logger carried by ctx, or nil
+func LoggerFrom(ctx context.Context) Logger {
+	l, _ := ctx.Value(loggerKey{}).(Logger)
+	return l
 }
 
 // Option configures the HttpClient
 type Option func(*HttpClient)
 
+// RequestOption configures a single request
+type RequestOption func(*http.Request)
+
 // WithTimeout sets the timeout for the HttpClient
 func WithTimeout(d time.Duration) Option {
 	return func(hc *HttpClient) {
@@ -32,6 +64,15 @@ func WithUserAgent(ua string) Option {
 	}
 }
 
+// WithMetrics records the requests of the HttpClient in r, by host and status
+// code. Requests that got no response are counted with the "error" code.
+func WithMetrics(r *metrics.Registry) Option {
+	return func(hc *HttpClient) {
+		hc.requests = r.Counter("productscout_http_requests_total", "HTTP requests by host and status code.", "host", "code")
+		hc.durations = r.Histogram("productscout_http_request_duration_seconds", "HTTP request latency by host.", nil, "host")
+	}
+}
+
 // NewHttpClient creates a new instance of HttpClient with optional configurations
 func NewHttpClient(opts ...Option) *HttpClient {
 	hc := &HttpClient{
@@ -44,13 +85,27 @@ func NewHttpClient(opts ...Option) *HttpClient {
 	return hc
 }
 
+// SetHeaders sets headers sent with every request of the HttpClient. Headers
+// specific to one caller should rather go through the Headers request option,
+// since the HttpClient may be shared.
 func (hc *HttpClient) SetHeaders(headers map[string]string) {
 	hc.headers = headers
 }
 
-// GetJSON performs a GET request and decodes the JSON response into the target interface{}
-func (hc *HttpClient) GetJSON(url string, target interface{}) error {
-	req, err := http.NewRequest("GET", url, nil)
+// Headers sets headers on a single request, overriding the HttpClient ones
+func Headers(headers map[string]string) RequestOption {
+	return func(req *http.Request) {
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+	}
+}
+
+// GetJSON performs a GET request bound to ctx and decodes the JSON response into the target interface{}.
+// Failures wrap ErrRateLimited, ErrBlocked, ErrUnauthorized, ErrSchemaChanged
+// or ErrUpstreamUnavailable when they fall in one of those classes.
+func (hc *HttpClient) GetJSON(ctx context.Context, url string, target interface{}, opts ...RequestOption) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return err
 	}
@@ -61,20 +116,61 @@ func (hc *HttpClient) GetJSON(url string, target interface{}) error {
 
 	req.Header.Set("User-Agent", hc.userAgent)
 
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	start := time.Now()
 	resp, err := hc.client.Do(req)
+	elapsed := time.Since(start)
+	hc.observe(req.URL, resp, elapsed)
+	logger := LoggerFrom(ctx)
 	if err != nil {
-		return err
+		if logger != nil {
+			logger.Debug("http request failed", "url", url, "duration_ms", elapsed.Milliseconds(), "err", err)
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %w", ErrUpstreamUnavailable, err)
 	}
 	defer resp.Body.Close()
 
+	if logger != nil {
+		logger.Debug("http request", "url", url, "status_code", resp.StatusCode, "duration_ms", elapsed.Milliseconds())
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code [%d] for the URL [%s]", resp.StatusCode, url)
+		return &StatusError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		if ctx.Err() != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %w", ErrUpstreamUnavailable, err)
+	}
+
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("%w: %w", ErrSchemaChanged, err)
 	}
+	return nil
+}
 
-	return json.Unmarshal(body, target)
+func (hc *HttpClient) observe(u *url.URL, resp *http.Response, elapsed time.Duration) {
+	if hc.requests == nil {
+		return
+	}
+
+	code := "error"
+	if resp != nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	hc.requests.Inc(u.Host, code)
+	hc.durations.Observe(elapsed.Seconds(), u.Host)
 }
@@ -1,25 +1,153 @@
 package core
 
-import "log"
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"achapromo.com/productscout/httpclient"
+)
 
 type (
+	// DefaultLogger writes text records, debug ones included, to stderr. The
+	// Engine only emits debug records in debug mode.
 	DefaultLogger struct{}
 
+	// Logger is a structured logger: each record has a message followed by
+	// key/value attributes. *slog.Logger implements it.
 	Logger interface {
+		Debug(msg string, args ...any)
+		Info(msg string, args ...any)
+		Warn(msg string, args ...any)
+		Error(msg string, args ...any)
+	}
+
+	// LegacyLogger is the unstructured Logger of earlier versions. Adapt one
+	// with FromLegacyLogger.
+	//
+	// Deprecated: implement Logger, or use NewLogger with a slog.Handler.
+	LegacyLogger interface {
 		Info(args ...interface{})
 		Error(args ...interface{})
 		Debug(args ...interface{})
 	}
+
+	// legacyLogger adapts a LegacyLogger to Logger
+	legacyLogger struct {
+		logger LegacyLogger
+	}
+
+	// scopedLogger adds attributes to every record and drops debug records
+	// outside debug mode
+	scopedLogger struct {
+		logger Logger
+		attrs  []any
+		debug  bool
+	}
 )
 
-func (l DefaultLogger) Info(args ...interface{}) {
-	log.Println(args...)
+var (
+	defaultLogger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	discardLogger = slog.New(discardHandler{})
+)
+
+// NewLogger returns a Logger writing to h
+func NewLogger(h slog.Handler) Logger {
+	return slog.New(h)
+}
+
+func (l DefaultLogger) Debug(msg string, args ...any) {
+	defaultLogger.Debug(msg, args...)
+}
+
+func (l DefaultLogger) Info(msg string, args ...any) {
+	defaultLogger.Info(msg, args...)
 }
 
-func (l DefaultLogger) Error(args ...interface{}) {
-	log.Println(args...)
+func (l DefaultLogger) Warn(msg string, args ...any) {
+	defaultLogger.Warn(msg, args...)
 }
 
-func (l DefaultLogger) Debug(args ...interface{}) {
-	log.Println(args...)
+func (l DefaultLogger) Error(msg string, args ...any) {
+	defaultLogger.Error(msg, args...)
 }
+
+// ContextWithLogger returns a copy of ctx carrying l. The Engine hands each
+// scraper a context whose logger already has the scraper and query
+// attributes, which the HttpClient also logs through.
+func ContextWithLogger(ctx context.Context, l Logger) context.Context {
+	return httpclient.ContextWithLogger(ctx, l)
+}
+
+// LoggerFrom returns the logger carried by ctx, or a logger discarding every
+// record
+func LoggerFrom(ctx context.Context) Logger {
+	if l, ok := httpclient.LoggerFrom(ctx).(Logger); ok {
+		return l
+	}
+	return discardLogger
+}
+
+// FromLegacyLogger adapts a logger written for the unstructured Logger of
+// earlier versions. Each record becomes its message followed by key=value
+// pairs, and warnings go to Info, which the old interface had no level for.
+func FromLegacyLogger(l LegacyLogger) Logger {
+	return &legacyLogger{logger: l}
+}
+
+func (l *legacyLogger) Debug(msg string, args ...any) {
+	l.logger.Debug(legacyArgs(msg, args)...)
+}
+
+func (l *legacyLogger) Info(msg string, args ...any) {
+	l.logger.Info(legacyArgs(msg, args)...)
+}
+
+func (l *legacyLogger) Warn(msg string, args ...any) {
+	l.logger.Info(legacyArgs(msg, args)...)
+}
+
+func (l *legacyLogger) Error(msg string, args ...any) {
+	l.logger.Error(legacyArgs(msg, args)...)
+}
+
+// legacyArgs flattens a structured record into the values of an unstructured
+// one
+func legacyArgs(msg string, args []any) []interface{} {
+	values := []interface{}{msg}
+	for i := 0; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			values = append(values, args[i])
+			break
+		}
+		values = append(values, fmt.Sprintf("%v=%v", args[i], args[i+1]))
+	}
+	return values
+}
+
+func (s *scopedLogger) Debug(msg string, args ...any) {
+	if s.debug {
+		s.logger.Debug(msg, append(s.attrs[:len(s.attrs):len(s.attrs)], args...)...)
+	}
+}
+
+func (s *scopedLogger) Info(msg string, args ...any) {
+	s.logger.Info(msg, append(s.attrs[:len(s.attrs):len(s.attrs)], args...)...)
+}
+
+func (s *scopedLogger) Warn(msg string, args ...any) {
+	s.logger.Warn(msg, append(s.attrs[:len(s.attrs):len(s.attrs)], args...)...)
+}
+
+func (s *scopedLogger) Error(msg string, args ...any) {
+	s.logger.Error(msg, append(s.attrs[:len(s.attrs):len(s.attrs)], args...)...)
+}
+
+// discardHandler drops every record
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (d discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return d }
+func (d discardHandler) WithGroup(string) slog.Handler           { return d }
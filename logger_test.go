@@ -0,0 +1,147 @@
+package core_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	core "achapromo.com/productscout"
+	"achapromo.com/productscout/httpclient"
+	"github.com/stretchr/testify/assert"
+)
+
+// records decodes the JSON lines written by a slog.JSONHandler
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var out []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		record := map[string]any{}
+		if assert.NoError(t, json.Unmarshal([]byte(line), &record)) {
+			out = append(out, record)
+		}
+	}
+	return out
+}
+
+func TestEngine_Logger_Structured(t *testing.T) {
+	var buf bytes.Buffer
+	engine := core.NewEngine(
+		core.WithLogger(core.NewLogger(slog.NewJSONHandler(&buf, nil))),
+		core.WithScrapers(&fakeScraper{url: "https://broken.example", err: assert.AnError}),
+	)
+
+	_, err := engine.Search("7898215151784")
+
+	assert.ErrorIs(t, err, assert.AnError)
+	logged := records(t, &buf)
+	if assert.Len(t, logged, 1) {
+		assert.Equal(t, "ERROR", logged[0]["level"])
+		assert.Equal(t, "scraper failed", logged[0]["msg"])
+		assert.Equal(t, "https://broken.example", logged[0]["scraper"])
+		assert.Equal(t, "7898215151784", logged[0]["query"])
+		assert.Equal(t, "error", logged[0]["status"])
+		assert.Contains(t, logged[0], "duration_ms")
+	}
+}
+
+// httpScraper fetches its products through an HttpClient
+type httpScraper struct {
+	url    string
+	client *httpclient.HttpClient
+}
+
+func (h *httpScraper) Scrape(ctx context.Context, query string) ([]core.Product, error) {
+	var products []core.Product
+	err := h.client.GetJSON(ctx, h.url+"?q="+query, &products)
+	return products, err
+}
+
+func (h *httpScraper) Info() core.Website {
+	return core.Website{ID: "http.example", URL: h.url}
+}
+
+func TestEngine_Logger_ScrapersLogThroughContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"Name": "Served", "GTIN": "7898215151784"}]`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	engine := core.NewEngine(
+		core.WithDebug(),
+		core.WithLogger(core.NewLogger(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+		core.WithScrapers(&httpScraper{url: server.URL, client: httpclient.NewHttpClient()}),
+	)
+
+	products, err := engine.Search("7898215151784")
+
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+
+	var request map[string]any
+	for _, record := range records(t, &buf) {
+		if record["msg"] == "http request" {
+			request = record
+		}
+	}
+	if assert.NotNil(t, request) {
+		assert.Equal(t, "http.example", request["scraper"])
+		assert.Equal(t, "7898215151784", request["query"])
+		assert.Equal(t, float64(200), request["status_code"])
+		assert.Contains(t, request, "duration_ms")
+	}
+}
+
+func TestEngine_Logger_DebugOnlyInDebugMode(t *testing.T) {
+	var buf bytes.Buffer
+	engine := core.NewEngine(
+		core.WithLogger(core.NewLogger(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+		core.WithScrapers(&fakeScraper{url: "https://missing.example", err: core.ErrProductNotFound}),
+	)
+
+	_, err := engine.Search("7898215151784")
+
+	assert.NoError(t, err)
+	assert.Empty(t, buf.String())
+}
+
+func TestLoggerFrom_Discards(t *testing.T) {
+	assert.NotPanics(t, func() {
+		core.LoggerFrom(context.Background()).Info("dropped")
+	})
+}
+
+// printLogger is a logger written for the unstructured Logger
+type printLogger struct {
+	lines []string
+}
+
+func (p *printLogger) Info(args ...interface{}) {
+	p.lines = append(p.lines, "INFO "+fmt.Sprintln(args...))
+}
+
+func (p *printLogger) Error(args ...interface{}) {
+	p.lines = append(p.lines, "ERROR "+fmt.Sprintln(args...))
+}
+
+func (p *printLogger) Debug(args ...interface{}) {
+	p.lines = append(p.lines, "DEBUG "+fmt.Sprintln(args...))
+}
+
+func TestFromLegacyLogger(t *testing.T) {
+	legacy := &printLogger{}
+	logger := core.FromLegacyLogger(legacy)
+
+	logger.Error("scraper failed", "scraper", "flaky.example", "attempts", 2)
+	logger.Warn("cache lookup failed", "dangling")
+
+	assert.Equal(t, []string{
+		"ERROR scraper failed scraper=flaky.example attempts=2\n",
+		"INFO cache lookup failed dangling\n",
+	}, legacy.lines)
+}
@@ -0,0 +1,173 @@
+package core
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"achapromo.com/productscout/gtin"
+)
+
+type (
+	// Observation is what a single source reported for a product
+	Observation struct {
+		Name    string
+		URL     string
+		Website Website
+	}
+
+	// MergedProduct is the canonical record of a product found on one or more
+	// sources. The embedded Product holds the canonical values and
+	// Observations keeps what every source reported, for provenance.
+	MergedProduct struct {
+		Product
+		Observations []Observation
+	}
+)
+
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// Merge groups products by normalized GTIN into one canonical record each.
+// The canonical name is the one that agrees the most with the names reported
+// by the other sources, descriptive fields missing from the first product are
+// completed with the other ones, and the offers of all sources are gathered
+// with the price set to the cheapest of them. Without offers, the price is
+// the cheapest one the sources reported on the products themselves.
+// Products without a GTIN cannot be matched and are kept as records of their
+// own. Records follow the order of first appearance.
+func Merge(products []Product) []MergedProduct {
+	merged := []MergedProduct{}
+	listed := [][]Offer{} // Prices set on the products, per record
+	byGTIN := map[string]int{}
+
+	for _, product := range products {
+		key := normalizeGTIN(product.GTIN)
+		observation := Observation{Name: product.Name, URL: product.URL, Website: product.Source}
+		price := listedOffer(product)
+
+		if idx, ok := byGTIN[key]; ok && key != "" {
+			merged[idx].Observations = append(merged[idx].Observations, observation)
+			merged[idx].fillMissing(product)
+			merged[idx].Offers = append(merged[idx].Offers, product.Offers...)
+			listed[idx] = append(listed[idx], price)
+			continue
+		}
+
+		record := MergedProduct{Product: product, Observations: []Observation{observation}}
+		record.Images = slices.Clone(product.Images) // Cloned so merging never writes into the input
+		record.Categories = slices.Clone(product.Categories)
+		record.Offers = slices.Clone(product.Offers)
+		if key != "" {
+			record.GTIN = key
+			byGTIN[key] = len(merged)
+		}
+		merged = append(merged, record)
+		listed = append(listed, []Offer{price})
+	}
+
+	for i := range merged {
+		best := bestObservation(merged[i].Observations)
+		merged[i].Name = best.Name
+		merged[i].URL = best.URL
+		merged[i].Source = best.Website
+
+		offer, ok := cheapestOffer(merged[i].Offers)
+		if !ok {
+			offer, ok = cheapestOffer(listed[i])
+		}
+		if ok {
+			merged[i].Price, merged[i].ListPrice = offer.Price, offer.ListPrice
+			merged[i].Currency, merged[i].Availability = offer.Currency, offer.Availability
+		}
+	}
+
+	return merged
+}
+
+// Merge groups the products of the search into one record per GTIN
+func (r *SearchResult) Merge() []MergedProduct {
+	return Merge(r.Products)
+}
+
+// bestObservation picks the observation whose name is the most similar to
+// all the others. Ties go to the most descriptive name, then to the first one.
+func bestObservation(observations []Observation) Observation {
+	tokens := make([][]string, len(observations))
+	for i, observation := range observations {
+		tokens[i] = tokenize(observation.Name)
+	}
+
+	best, bestScore := 0, -1.0
+	for i := range observations {
+		if len(tokens[i]) == 0 {
+			continue
+		}
+
+		score := 0.0
+		for j := range observations {
+			if i != j {
+				score += similarity(tokens[i], tokens[j])
+			}
+		}
+
+		if score > bestScore || (score == bestScore && len(tokens[i]) > len(tokens[best])) {
+			best, bestScore = i, score
+		}
+	}
+
+	return observations[best]
+}
+
+// normalizeGTIN returns the canonical form of a valid GTIN. Codes that are
+// not valid GTINs, like store-specific ids, are only trimmed so they still
+// group with identical codes.
+func normalizeGTIN(s string) string {
+	if g, err := gtin.Parse(s); err == nil {
+		return g.String()
+	}
+	return strings.TrimSpace(s)
+}
+
+// tokenize lowercases name, strips accents and splits it into words
+func tokenize(name string) []string {
+	name = accents.Replace(strings.ToLower(name))
+	return strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// similarity is the Jaccard index of the two token sets
+func similarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	set := make(map[string]bool, len(a))
+	for _, token := range a {
+		set[token] = true
+	}
+
+	intersection := 0
+	union := len(set)
+	seen := make(map[string]bool, len(b))
+	for _, token := range b {
+		if seen[token] {
+			continue
+		}
+		seen[token] = true
+		if set[token] {
+			intersection++
+		} else {
+			union++
+		}
+	}
+
+	return float64(intersection) / float64(union)
+}
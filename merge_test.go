@@ -0,0 +1,37 @@
+package core_test
+
+import (
+	"testing"
+
+	core "achapromo.com/productscout"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	off := core.Website{URL: "https://world.openfoodfacts.org"}
+	bm := core.Website{URL: "https://barcode.monster"}
+	pm := core.Website{URL: "https://prod.apipmenos.com"}
+
+	merged := core.Merge([]core.Product{
+		{Name: "Creme de Leite Piracanjuba Caixinha 200g", GTIN: "7898215151784", URL: "https://off/7898215151784", Source: off},
+		{Name: "CREME LEITE PIRAC 200G", GTIN: "07898215151784", URL: "https://bm/7898215151784", Source: bm, Images: []string{"https://bm/7898215151784.jpg"}},
+		{Name: "Creme de Leite Piracanjuba 200g", GTIN: "7898215151784", URL: "https://pm/creme", Source: pm, Brand: "Piracanjuba", Price: 3.49},
+		{Name: "Sabão em pó", GTIN: "", URL: "https://pm/sabao", Source: pm},
+	})
+
+	if !assert.Len(t, merged, 2) {
+		return
+	}
+	assert.Equal(t, "7898215151784", merged[0].GTIN)
+	assert.Equal(t, "Creme de Leite Piracanjuba 200g", merged[0].Name)
+	assert.Equal(t, "https://pm/creme", merged[0].URL)
+	assert.Equal(t, pm, merged[0].Source)
+	assert.Equal(t, "Piracanjuba", merged[0].Brand)
+	assert.Equal(t, []string{"https://bm/7898215151784.jpg"}, merged[0].Images)
+	assert.Equal(t, 3.49, merged[0].Price, "without offers, the price comes from the products")
+	if assert.Len(t, merged[0].Observations, 3) {
+		assert.Equal(t, core.Observation{Name: "CREME LEITE PIRAC 200G", URL: "https://bm/7898215151784", Website: bm}, merged[0].Observations[1])
+	}
+	assert.Equal(t, "Sabão em pó", merged[1].Name)
+	assert.Len(t, merged[1].Observations, 1)
+}
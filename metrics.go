@@ -0,0 +1,57 @@
+package core
+
+import (
+	"context"
+
+	"achapromo.com/productscout/metrics"
+)
+
+// WithMetrics records searches, scraper calls and cache lookups in r: their
+// counts, latencies, statuses and error classes. Scrapers built by
+// NewEngineFromConfig also record their HTTP requests in r.
+func WithMetrics(r *metrics.Registry) Option {
+	searches := r.Counter("productscout_searches_total", "Searches by query type.", "type")
+	searchDuration := r.Histogram("productscout_search_duration_seconds", "Search latency.", nil)
+	requests := r.Counter("productscout_scraper_requests_total", "Scraper calls by status.", "scraper", "status")
+	failures := r.Counter("productscout_scraper_errors_total", "Scraper failures by error class.", "scraper", "class")
+	skipped := r.Counter("productscout_scraper_skipped_total", "Scrapers skipped by the engine.", "scraper")
+	duration := r.Histogram("productscout_scraper_duration_seconds", "Scraper latency, retries included.", nil, "scraper")
+	filtered := r.Counter("productscout_filtered_products_total", "Products dropped for not matching the queried GTIN.", "scraper")
+	lookups := r.Counter("productscout_cache_lookups_total", "Cache lookups by result, hit or miss.", "scraper", "result")
+
+	return func(e *Engine) {
+		e.config.Metrics = r
+		WithHooks(Hooks{
+			ScraperFinished: func(ctx context.Context, event ScraperFinishedEvent) {
+				scraper := event.Report.Website.key()
+				requests.Inc(scraper, string(event.Report.Status))
+				duration.Observe(event.Report.Latency.Seconds(), scraper)
+				if event.Report.Status != StatusOK && event.Report.Status != StatusNotFound {
+					failures.Inc(scraper, errorClass(event.Report.Err))
+				}
+			},
+			CacheLookup: func(ctx context.Context, event CacheLookupEvent) {
+				result := "miss"
+				if event.Hit {
+					result = "hit"
+				}
+				lookups.Inc(event.Website.key(), result)
+			},
+			ResultFiltered: func(ctx context.Context, event ResultFilteredEvent) {
+				filtered.Add(float64(event.Dropped), event.Website.key())
+			},
+			SearchFinished: func(ctx context.Context, event SearchFinishedEvent) {
+				searches.Inc(string(QueryTypeOf(event.Query)))
+				searchDuration.Observe(event.Duration.Seconds())
+				if event.Result == nil {
+					return
+				}
+				for _, source := range event.Result.Sources {
+					if source.Status == StatusSkipped {
+						skipped.Inc(source.Website.key())
+					}
+				}
+			},
+		})(e)
+	}
+}
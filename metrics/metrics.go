@@ -0,0 +1,323 @@
+// Package metrics keeps counters and histograms in a Registry and exposes
+// them in the Prometheus text format, without any external dependency.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets suit latencies in seconds, from 5ms to 10s
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type (
+	// Registry holds metrics by name. It is safe for concurrent use.
+	Registry struct {
+		mu      sync.Mutex
+		metrics map[string]metric
+	}
+
+	metric interface {
+		write(w io.Writer) error
+	}
+
+	// desc holds what every metric has: a name, a help text and label names
+	desc struct {
+		name   string
+		help   string
+		labels []string
+	}
+
+	// Counter is a monotonically increasing value per label values
+	Counter struct {
+		desc
+		mu     sync.Mutex
+		values map[string]*counterValue
+	}
+
+	counterValue struct {
+		labels []string
+		value  float64
+	}
+
+	// Histogram counts observations in cumulative buckets per label values
+	Histogram struct {
+		desc
+		buckets []float64
+		mu      sync.Mutex
+		values  map[string]*histogramValue
+	}
+
+	histogramValue struct {
+		labels []string
+		counts []uint64 // Per bucket, not cumulative
+		count  uint64
+		sum    float64
+	}
+)
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{metrics: map[string]metric{}}
+}
+
+// Counter returns the counter registered under name, creating it on first
+// use. It panics if name is taken by a metric of another kind or with other
+// label names.
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if m, ok := r.metrics[name]; ok {
+		c, ok := m.(*Counter)
+		if !ok {
+			panic(fmt.Sprintf("metrics: %s is not a counter", name))
+		}
+		c.check(labels)
+		return c
+	}
+
+	c := &Counter{desc: desc{name: name, help: help, labels: labels}, values: map[string]*counterValue{}}
+	r.metrics[name] = c
+	return c
+}
+
+// Histogram returns the histogram registered under name, creating it on
+// first use with buckets, or DefaultBuckets when nil. It panics if name is
+// taken by a metric of another kind or with other label names.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if m, ok := r.metrics[name]; ok {
+		h, ok := m.(*Histogram)
+		if !ok {
+			panic(fmt.Sprintf("metrics: %s is not a histogram", name))
+		}
+		h.check(labels)
+		return h
+	}
+
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+
+	h := &Histogram{desc: desc{name: name, help: help, labels: labels}, buckets: buckets, values: map[string]*histogramValue{}}
+	r.metrics[name] = h
+	return h
+}
+
+// Inc adds one to the counter of the given label values
+func (c *Counter) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+// Add adds v, which must not be negative, to the counter of the given label
+// values
+func (c *Counter) Add(v float64, labels ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("metrics: counter %s cannot decrease", c.name))
+	}
+	key := c.key(labels)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.values[key]
+	if !ok {
+		value = &counterValue{labels: slices.Clone(labels)}
+		c.values[key] = value
+	}
+	value.value += v
+}
+
+// Value returns the counter of the given label values
+func (c *Counter) Value(labels ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if value, ok := c.values[c.key(labels)]; ok {
+		return value.value
+	}
+	return 0
+}
+
+// Observe records v in the histogram of the given label values
+func (h *Histogram) Observe(v float64, labels ...string) {
+	key := h.key(labels)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	value, ok := h.values[key]
+	if !ok {
+		value = &histogramValue{labels: slices.Clone(labels), counts: make([]uint64, len(h.buckets))}
+		h.values[key] = value
+	}
+	if i, _ := slices.BinarySearch(h.buckets, v); i < len(h.buckets) {
+		value.counts[i]++
+	}
+	value.count++
+	value.sum += v
+}
+
+// Count returns how many values the histogram of the given label values
+// observed
+func (h *Histogram) Count(labels ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if value, ok := h.values[h.key(labels)]; ok {
+		return value.count
+	}
+	return 0
+}
+
+// check panics unless labels are the label names of the metric, so two
+// callers cannot share a name with different meanings
+func (d desc) check(labels []string) {
+	if !slices.Equal(labels, d.labels) {
+		panic(fmt.Sprintf("metrics: %s has labels %v, not %v", d.name, d.labels, labels))
+	}
+}
+
+// key checks the label values against the label names and joins them
+func (d desc) key(labels []string) string {
+	if len(labels) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.name, len(d.labels), len(labels)))
+	}
+	return strings.Join(labels, "\xff")
+}
+
+// WriteTo writes every metric in the Prometheus text format, sorted by name
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	names := sortedKeys(r.metrics)
+	metrics := make([]metric, len(names))
+	for i, name := range names {
+		metrics[i] = r.metrics[name]
+	}
+	r.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	for _, m := range metrics {
+		if err := m.write(cw); err != nil {
+			return cw.n, err
+		}
+	}
+	return cw.n, nil
+}
+
+// Handler serves the metrics of the registry in the Prometheus text format
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteTo(w)
+	})
+}
+
+func (c *Counter) write(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.header(w, "counter"); err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(c.values) {
+		value := c.values[key]
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", c.name, c.labelSet(value.labels), formatFloat(value.value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *Histogram) write(w io.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.header(w, "histogram"); err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(h.values) {
+		value := h.values[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += value.counts[i]
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelSet(value.labels, "le", formatFloat(bound)), cumulative); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			h.name, h.labelSet(value.labels, "le", "+Inf"), value.count,
+			h.name, h.labelSet(value.labels), formatFloat(value.sum),
+			h.name, h.labelSet(value.labels), value.count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d desc) header(w io.Writer, kind string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, kind)
+	return err
+}
+
+// labelSet renders the label values, followed by extra name/value pairs, as
+// {name="value",...}
+func (d desc) labelSet(values []string, extra ...string) string {
+	if len(values) == 0 && len(extra) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(values)+len(extra)/2)
+	for i, value := range values {
+		pairs = append(pairs, d.labels[i]+`="`+escapeLabel(value)+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+escapeLabel(extra[i+1])+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,80 @@
+package metrics_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"achapromo.com/productscout/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_WriteTo(t *testing.T) {
+	r := metrics.NewRegistry()
+	requests := r.Counter("requests_total", "Requests by code.", "host", "code")
+	latency := r.Histogram("latency_seconds", "Latency.", []float64{0.5, 0.1}, "host")
+
+	requests.Inc("a.example", "200")
+	requests.Add(2, "a.example", "500")
+	requests.Inc(`quoted"host`, "200")
+	latency.Observe(0.05, "a.example")
+	latency.Observe(0.1, "a.example")
+	latency.Observe(3, "a.example")
+
+	var out strings.Builder
+	_, err := r.WriteTo(&out)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{host="a.example",le="0.1"} 2
+latency_seconds_bucket{host="a.example",le="0.5"} 2
+latency_seconds_bucket{host="a.example",le="+Inf"} 3
+latency_seconds_sum{host="a.example"} 3.15
+latency_seconds_count{host="a.example"} 3
+# HELP requests_total Requests by code.
+# TYPE requests_total counter
+requests_total{host="a.example",code="200"} 1
+requests_total{host="a.example",code="500"} 2
+requests_total{host="quoted\"host",code="200"} 1
+`, out.String())
+}
+
+func TestRegistry_ReturnsRegisteredMetric(t *testing.T) {
+	r := metrics.NewRegistry()
+
+	r.Counter("hits_total", "Hits.").Inc()
+	r.Counter("hits_total", "Hits.").Inc()
+
+	assert.Equal(t, float64(2), r.Counter("hits_total", "Hits.").Value())
+	assert.Panics(t, func() { r.Histogram("hits_total", "Hits.", nil) })
+}
+
+func TestCounter_LabelMismatch(t *testing.T) {
+	c := metrics.NewRegistry().Counter("hits_total", "Hits.", "cache")
+
+	assert.Panics(t, func() { c.Inc() })
+	assert.Panics(t, func() { c.Add(-1, "memory") })
+}
+
+func TestRegistry_LabelNamesMismatch(t *testing.T) {
+	r := metrics.NewRegistry()
+	r.Counter("hits_total", "Hits.", "cache")
+	r.Histogram("latency_seconds", "Latency.", nil, "cache")
+
+	assert.NotPanics(t, func() { r.Counter("hits_total", "Hits.", "cache") })
+	assert.Panics(t, func() { r.Counter("hits_total", "Hits.", "store") })
+	assert.Panics(t, func() { r.Counter("hits_total", "Hits.") })
+	assert.Panics(t, func() { r.Histogram("latency_seconds", "Latency.", nil, "cache", "result") })
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	r := metrics.NewRegistry()
+	r.Counter("hits_total", "Hits.").Inc()
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "hits_total 1\n")
+}
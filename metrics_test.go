@@ -0,0 +1,35 @@
+package core_test
+
+import (
+	"context"
+	"testing"
+
+	core "achapromo.com/productscout"
+	"achapromo.com/productscout/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngine_Metrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	engine := core.NewEngine(
+		core.WithMetrics(registry),
+		core.WithScrapers(
+			&fakeScraper{url: "https://a.example", products: []core.Product{
+				{Name: "Match", GTIN: "7898215151784"},
+				{Name: "Other", GTIN: "7898422745523"},
+			}},
+			&fakeScraper{url: "https://broken.example", err: assert.AnError},
+			&fakeScraper{url: "https://text.example", queryTypes: []core.QueryType{core.QueryText}},
+		),
+	)
+
+	_, err := engine.SearchContext(context.Background(), "7898215151784")
+
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1), registry.Counter("productscout_searches_total", "", "type").Value("gtin"))
+	assert.Equal(t, float64(1), registry.Counter("productscout_scraper_requests_total", "", "scraper", "status").Value("https://a.example", "ok"))
+	assert.Equal(t, float64(1), registry.Counter("productscout_scraper_errors_total", "", "scraper", "class").Value("https://broken.example", "other"))
+	assert.Equal(t, float64(1), registry.Counter("productscout_scraper_skipped_total", "", "scraper").Value("https://text.example"))
+	assert.Equal(t, float64(1), registry.Counter("productscout_filtered_products_total", "", "scraper").Value("https://a.example"))
+	assert.Equal(t, uint64(1), registry.Histogram("productscout_search_duration_seconds", "", nil).Count())
+}
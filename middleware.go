@@ -0,0 +1,239 @@
+package core
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"achapromo.com/productscout/metrics"
+)
+
+// Middleware decorates a Scraper with a cross-cutting concern such as
+// logging, retries or caching. It must keep the Info of the scraper it wraps.
+type Middleware func(Scraper) Scraper
+
+// ScrapeFunc is the signature of Scraper.Scrape
+type ScrapeFunc func(ctx context.Context, query string) ([]Product, error)
+
+type decorated struct {
+	Scraper
+	scrape ScrapeFunc
+}
+
+func (d *decorated) Scrape(ctx context.Context, query string) ([]Product, error) {
+	return d.scrape(ctx, query)
+}
+
+// Decorate returns a Scraper with the Info of next whose Scrape calls scrape.
+// It is the building block of most middlewares.
+func Decorate(next Scraper, scrape ScrapeFunc) Scraper {
+	return &decorated{Scraper: next, scrape: scrape}
+}
+
+// Wrap applies mw to s. The first middleware is the outermost one, so it sees
+// the query first and the products last.
+func Wrap(s Scraper, mw ...Middleware) Scraper {
+	for i := len(mw) - 1; i >= 0; i-- {
+		s = mw[i](s)
+	}
+	return s
+}
+
+// WithMiddleware wraps every scraper of the Engine with mw. Middlewares run
+// around each call to Scrape, inside the retries and the circuit breaker of
+// the Engine.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(e *Engine) {
+		e.config.Middleware = append(e.config.Middleware, mw...)
+	}
+}
+
+// WithScraperMiddleware wraps the scraper whose Website has the given ID with
+// mw, inside the middlewares set with WithMiddleware
+func WithScraperMiddleware(id string, mw ...Middleware) Option {
+	return func(e *Engine) {
+		if e.config.ScraperMiddleware == nil {
+			e.config.ScraperMiddleware = map[string][]Middleware{}
+		}
+		e.config.ScraperMiddleware[id] = append(e.config.ScraperMiddleware[id], mw...)
+	}
+}
+
+// wrap applies the global and the per-scraper middlewares to s. The Engine
+// wraps each scraper once, when it is registered.
+func (e *Engine) wrap(s Scraper) Scraper {
+	if own := e.config.ScraperMiddleware[s.Info().key()]; len(own) > 0 {
+		s = Wrap(s, own...)
+	}
+	return Wrap(s, e.config.Middleware...)
+}
+
+// Logging logs every call to Scrape with its query, outcome and duration
+func Logging(logger Logger) Middleware {
+	return func(next Scraper) Scraper {
+		return Decorate(next, func(ctx context.Context, query string) ([]Product, error) {
+			start := time.Now()
+			products, err := next.Scrape(ctx, query)
+			attrs := []any{"scraper", next.Info().key(), "query", query, "duration_ms", time.Since(start).Milliseconds()}
+
+			if err != nil {
+				logger.Debug("scrape failed", append(attrs, "err", err)...)
+			} else {
+				logger.Debug("scrape", append(attrs, "count", len(products))...)
+			}
+			return products, err
+		})
+	}
+}
+
+// Retry repeats failed calls up to retries times, waiting backoff before the
+// first retry and doubling it for each next one. Like ScraperPolicy, it never
+// retries not found answers or cancellations.
+func Retry(retries int, backoff time.Duration) Middleware {
+	return func(next Scraper) Scraper {
+		return Decorate(next, func(ctx context.Context, query string) ([]Product, error) {
+			wait := backoff
+			for attempt := 0; ; attempt++ {
+				products, err := next.Scrape(ctx, query)
+				if !retryable(ctx, err) || attempt >= retries {
+					return products, err
+				}
+
+				timer := time.NewTimer(wait)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					return products, err
+				}
+				wait *= 2
+			}
+		})
+	}
+}
+
+// Timeout bounds each call to Scrape by d
+func Timeout(d time.Duration) Middleware {
+	return func(next Scraper) Scraper {
+		return Decorate(next, func(ctx context.Context, query string) ([]Product, error) {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			return next.Scrape(ctx, query)
+		})
+	}
+}
+
+// Caching serves the answers of the scraper from c and stores its new ones,
+// with the rules of WithCache. Inside an Engine prefer WithCache, which also
+// serves cached answers while the circuit is open and emits CacheLookup hooks;
+// Caching suits a cache of its own for one scraper, or scrapers used alone.
+func Caching(c Cache, ttl CacheTTL) Middleware {
+	return func(next Scraper) Scraper {
+		website := next.Info()
+		return Decorate(next, func(ctx context.Context, query string) ([]Product, error) {
+			key := CacheKey(website, query)
+			entry, ok, err := c.Get(key)
+			if err != nil {
+				LoggerFrom(ctx).Warn("cache lookup failed", "key", key, "err", err)
+			}
+			if ok {
+				if entry.NotFound {
+					return nil, ErrProductNotFound
+				}
+				return entry.Clone().Products, nil
+			}
+
+			products, err := next.Scrape(ctx, query)
+			if entry, ttl := cacheEntry(products, err, ttl); ttl > 0 {
+				if err := c.Set(key, entry, ttl); err != nil {
+					LoggerFrom(ctx).Warn("cache store failed", "key", key, "err", err)
+				}
+			}
+			return products, err
+		})
+	}
+}
+
+// Metrics counts the calls to Scrape in r by status and records their
+// latency. Unlike WithMetrics, which sees one answer per scraper and search,
+// it sees every call, so each retry and each barcode form counts.
+func Metrics(r *metrics.Registry) Middleware {
+	calls := r.Counter("productscout_scrape_calls_total", "Calls to Scrape by status.", "scraper", "status")
+	duration := r.Histogram("productscout_scrape_call_duration_seconds", "Latency of a single call to Scrape.", nil, "scraper")
+
+	return func(next Scraper) Scraper {
+		scraper := next.Info().key()
+		return Decorate(next, func(ctx context.Context, query string) ([]Product, error) {
+			start := time.Now()
+			products, err := next.Scrape(ctx, query)
+			calls.Inc(scraper, string(statusOf(err)))
+			duration.Observe(time.Since(start).Seconds(), scraper)
+			return products, err
+		})
+	}
+}
+
+// Hedge starts a backup call to Scrape when the first one has not answered
+// after delay, and keeps whichever answers first; the other one is cancelled.
+// A failure that a retry could fix makes it wait for the other call, if any.
+// Hedging trades extra requests for a lower tail latency, so pick a delay
+// around the usual latency of the scraper.
+func Hedge(delay time.Duration) Middleware {
+	type answer struct {
+		products []Product
+		err      error
+	}
+
+	return func(next Scraper) Scraper {
+		return Decorate(next, func(ctx context.Context, query string) ([]Product, error) {
+			hedged, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			answers := make(chan answer, 2)
+			call := func() {
+				products, err := safeScrape(hedged, next, query)
+				answers <- answer{products, err}
+			}
+			go call()
+			pending := 1
+
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+
+			var err error
+			for pending > 0 {
+				select {
+				case <-timer.C:
+					go call()
+					pending++
+				case a := <-answers:
+					pending--
+					if !retryable(ctx, a.err) {
+						return a.products, a.err
+					}
+					err = a.err
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			}
+			return nil, err
+		})
+	}
+}
+
+// SanitizeNames trims product names and collapses their inner whitespace,
+// which some stores pad with line breaks and repeated spaces
+func SanitizeNames() Middleware {
+	return func(next Scraper) Scraper {
+		return Decorate(next, func(ctx context.Context, query string) ([]Product, error) {
+			products, err := next.Scrape(ctx, query)
+			products = slices.Clone(products) // Scrapers may hand out shared slices
+			for i := range products {
+				products[i].Name = strings.Join(strings.Fields(products[i].Name), " ")
+				products[i].Brand = strings.TrimSpace(products[i].Brand)
+			}
+			return products, err
+		})
+	}
+}
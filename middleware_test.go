@@ -0,0 +1,183 @@
+package core_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	core "achapromo.com/productscout"
+	"achapromo.com/productscout/cache"
+	"achapromo.com/productscout/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+// tag appends name to the product names, to observe the middleware order
+func tag(name string) core.Middleware {
+	return func(next core.Scraper) core.Scraper {
+		return core.Decorate(next, func(ctx context.Context, query string) ([]core.Product, error) {
+			products, err := next.Scrape(ctx, query)
+			for i := range products {
+				products[i].Name += " " + name
+			}
+			return products, err
+		})
+	}
+}
+
+func TestWrap_Order(t *testing.T) {
+	scraper := core.Wrap(&flakyScraper{id: "flaky.example"}, tag("inner"), tag("outer"))
+
+	products, err := scraper.Scrape(context.Background(), "7898215151784")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Recovered outer inner", products[0].Name)
+	assert.Equal(t, "flaky.example", scraper.Info().ID)
+}
+
+func TestEngine_Middleware(t *testing.T) {
+	engine := core.NewEngine(
+		core.WithScrapers(
+			&flakyScraper{id: "a.example"},
+			&flakyScraper{id: "b.example"},
+		),
+		core.WithMiddleware(tag("global")),
+		core.WithScraperMiddleware("b.example", tag("own")),
+	)
+
+	result, err := engine.SearchWithReport(context.Background(), "7898215151784")
+
+	assert.NoError(t, err)
+	if assert.Len(t, result.Products, 2) {
+		assert.Equal(t, "Recovered global", result.Products[0].Name)
+		assert.Equal(t, "Recovered own global", result.Products[1].Name)
+	}
+}
+
+func TestEngine_MiddlewareBuiltOnce(t *testing.T) {
+	built := 0
+	engine := core.NewEngine(
+		core.WithScrapers(&flakyScraper{id: "a.example"}),
+		core.WithMiddleware(func(next core.Scraper) core.Scraper {
+			built++
+			return next
+		}),
+	)
+
+	for i := 0; i < 3; i++ {
+		_, err := engine.Search("7898215151784")
+		assert.NoError(t, err)
+	}
+	engine.AddScraper(&flakyScraper{id: "b.example"})
+	_, _ = engine.Search("7898215151784")
+
+	assert.Equal(t, 2, built)
+}
+
+func TestCaching(t *testing.T) {
+	flaky := &flakyScraper{id: "flaky.example"}
+	scraper := core.Caching(cache.NewMemory(10), core.CacheTTL{Hit: time.Minute})(flaky)
+
+	for i := 0; i < 2; i++ {
+		products, err := scraper.Scrape(context.Background(), "7898215151784")
+		assert.NoError(t, err)
+		assert.Len(t, products, 1)
+	}
+
+	assert.Equal(t, 1, flaky.calls)
+}
+
+func TestMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	scraper := core.Metrics(registry)(&flakyScraper{id: "flaky.example", failures: 1})
+
+	_, _ = scraper.Scrape(context.Background(), "7898215151784")
+	_, _ = scraper.Scrape(context.Background(), "7898215151784")
+
+	calls := registry.Counter("productscout_scrape_calls_total", "", "scraper", "status")
+	assert.Equal(t, 1.0, calls.Value("flaky.example", "error"))
+	assert.Equal(t, 1.0, calls.Value("flaky.example", "ok"))
+	assert.Equal(t, uint64(2), registry.Histogram("productscout_scrape_call_duration_seconds", "", nil, "scraper").Count("flaky.example"))
+}
+
+func TestRetry(t *testing.T) {
+	flaky := &flakyScraper{id: "flaky.example", failures: 2}
+
+	products, err := core.Retry(2, time.Millisecond)(flaky).Scrape(context.Background(), "7898215151784")
+
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, 3, flaky.calls)
+}
+
+func TestRetry_GivesUp(t *testing.T) {
+	flaky := &flakyScraper{id: "flaky.example", failures: 5}
+
+	_, err := core.Retry(1, 0)(flaky).Scrape(context.Background(), "7898215151784")
+
+	assert.Error(t, err)
+	assert.Equal(t, 2, flaky.calls)
+}
+
+func TestTimeout(t *testing.T) {
+	scraper := core.Timeout(10 * time.Millisecond)(&fakeScraper{delay: time.Minute})
+
+	_, err := scraper.Scrape(context.Background(), "7898215151784")
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestSanitizeNames(t *testing.T) {
+	original := []core.Product{{Name: "  Creme de Leite\n  Piracanjuba   200g ", Brand: " Piracanjuba "}}
+	scraper := core.SanitizeNames()(&fakeScraper{products: original})
+
+	products, err := scraper.Scrape(context.Background(), "7898215151784")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Creme de Leite Piracanjuba 200g", products[0].Name)
+	assert.Equal(t, "Piracanjuba", products[0].Brand)
+	assert.Equal(t, "  Creme de Leite\n  Piracanjuba   200g ", original[0].Name, "the scraper slice is left untouched")
+}
+
+// stallingScraper hangs on its first call until cancelled and answers the
+// next ones at once
+type stallingScraper struct {
+	calls    atomic.Int32
+	canceled atomic.Bool
+}
+
+func (s *stallingScraper) Scrape(ctx context.Context, query string) ([]core.Product, error) {
+	if s.calls.Add(1) == 1 {
+		<-ctx.Done()
+		s.canceled.Store(true)
+		return nil, ctx.Err()
+	}
+	return []core.Product{{Name: "Backup"}}, nil
+}
+
+func (s *stallingScraper) Info() core.Website {
+	return core.Website{URL: "stalling.example"}
+}
+
+func TestHedge(t *testing.T) {
+	stalling := &stallingScraper{}
+
+	products, err := core.Hedge(10*time.Millisecond)(stalling).Scrape(context.Background(), "7898215151784")
+
+	assert.NoError(t, err)
+	if assert.Len(t, products, 1) {
+		assert.Equal(t, "Backup", products[0].Name)
+	}
+	assert.Equal(t, int32(2), stalling.calls.Load())
+	assert.Eventually(t, stalling.canceled.Load, time.Second, time.Millisecond, "the slow call is cancelled")
+}
+
+func TestHedge_FastAnswer(t *testing.T) {
+	flaky := &flakyScraper{id: "flaky.example"}
+
+	products, err := core.Hedge(time.Minute)(flaky).Scrape(context.Background(), "7898215151784")
+
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, 1, flaky.calls, "no backup call before the delay")
+}
@@ -0,0 +1,120 @@
+package core
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Offer is a price a store asks for a product at a given moment. A product
+// found on a marketplace can have one offer per seller and per SKU.
+type Offer struct {
+	Store  string
+	Seller string
+
+	Price     float64
+	ListPrice float64 // Price before discounts
+	Currency  string
+
+	// UnitPrice is Price per Unit ("kg", "l" or "un"), derived from the
+	// product quantity. Zero when the quantity is unknown.
+	UnitPrice float64
+	Unit      string
+
+	Availability Availability
+	Promo        string
+	ObservedAt   time.Time
+}
+
+var quantityRegex = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*(kg|g|mg|ml|l|lt|un|und|unid|unidades)\b`)
+
+// UnitPrice returns price per kilogram, liter or unit for a quantity like
+// "200 g", "1,5L" or "12 un". It returns zero and an empty unit when the
+// quantity cannot be parsed.
+func UnitPrice(price float64, quantity string) (float64, string) {
+	match := quantityRegex.FindStringSubmatch(quantity)
+	if match == nil || price <= 0 {
+		return 0, ""
+	}
+
+	amount, err := strconv.ParseFloat(strings.Replace(match[1], ",", ".", 1), 64)
+	if err != nil || amount <= 0 {
+		return 0, ""
+	}
+
+	switch strings.ToLower(match[2]) {
+	case "kg":
+		return price / amount, "kg"
+	case "g":
+		return price / (amount / 1000), "kg"
+	case "mg":
+		return price / (amount / 1000000), "kg"
+	case "l", "lt":
+		return price / amount, "l"
+	case "ml":
+		return price / (amount / 1000), "l"
+	default:
+		return price / amount, "un"
+	}
+}
+
+// CheapestOffers returns the cheapest priced offer per canonical GTIN.
+// Out-of-stock offers are ignored. A product without offers counts with the
+// price set on the product itself.
+func CheapestOffers(products []Product) map[string]Offer {
+	cheapest := map[string]Offer{}
+	for _, product := range products {
+		key := normalizeGTIN(product.GTIN)
+		if key == "" {
+			continue
+		}
+		offers := product.Offers
+		if len(offers) == 0 {
+			offers = []Offer{listedOffer(product)}
+		}
+		if offer, ok := cheapestOffer(offers); ok {
+			if current, found := cheapest[key]; !found || offer.Price < current.Price {
+				cheapest[key] = offer
+			}
+		}
+	}
+	return cheapest
+}
+
+// CheapestOffers returns the cheapest offer per GTIN found by the search
+func (r *SearchResult) CheapestOffers() map[string]Offer {
+	return CheapestOffers(r.Products)
+}
+
+// listedOffer is the offer made by the price set on product, for sources that
+// list no offers
+func listedOffer(product Product) Offer {
+	unitPrice, unit := UnitPrice(product.Price, product.Quantity)
+	offer := Offer{
+		Price:        product.Price,
+		ListPrice:    product.ListPrice,
+		Currency:     product.Currency,
+		UnitPrice:    unitPrice,
+		Unit:         unit,
+		Availability: product.Availability,
+	}
+	if product.Source.URL != "" {
+		offer.Store = product.Source.String()
+	}
+	return offer
+}
+
+func cheapestOffer(offers []Offer) (Offer, bool) {
+	var cheapest Offer
+	found := false
+	for _, offer := range offers {
+		if offer.Price <= 0 || offer.Availability == AvailabilityOutOfStock {
+			continue
+		}
+		if !found || offer.Price < cheapest.Price {
+			cheapest, found = offer, true
+		}
+	}
+	return cheapest, found
+}
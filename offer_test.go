@@ -0,0 +1,52 @@
+package core_test
+
+import (
+	"testing"
+
+	core "achapromo.com/productscout"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnitPrice(t *testing.T) {
+	tests := []struct {
+		quantity  string
+		unitPrice float64
+		unit      string
+	}{
+		{quantity: "200 g", unitPrice: 15, unit: "kg"},
+		{quantity: "1,5L", unitPrice: 2, unit: "l"},
+		{quantity: "500ml", unitPrice: 6, unit: "l"},
+		{quantity: "Caixa 12 un", unitPrice: 0.25, unit: "un"},
+		{quantity: "", unitPrice: 0, unit: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.quantity, func(t *testing.T) {
+			price := 3.0
+			unitPrice, unit := core.UnitPrice(price, tt.quantity)
+
+			assert.InDelta(t, tt.unitPrice, unitPrice, 0.0001)
+			assert.Equal(t, tt.unit, unit)
+		})
+	}
+}
+
+func TestCheapestOffers(t *testing.T) {
+	products := []core.Product{
+		{GTIN: "7898215151784", Offers: []core.Offer{
+			{Store: "a", Price: 4.99},
+			{Store: "b", Price: 2.99, Availability: core.AvailabilityOutOfStock},
+		}},
+		{GTIN: "07898215151784", Offers: []core.Offer{{Store: "c", Price: 3.49}}},
+		{GTIN: "7898422745523", Offers: []core.Offer{{Store: "a", Price: 0}}},
+		{GTIN: "7891000100103", Price: 12.90, ListPrice: 14.90, Currency: "BRL", Source: core.Website{URL: "https://food.example"}},
+		{GTIN: "7891000100103", Price: 9.90, Availability: core.AvailabilityOutOfStock},
+	}
+
+	cheapest := core.CheapestOffers(products)
+
+	assert.Len(t, cheapest, 2)
+	assert.Equal(t, "c", cheapest["7898215151784"].Store)
+	assert.Equal(t, core.Offer{Store: "https://food.example", Price: 12.90, ListPrice: 14.90, Currency: "BRL"}, cheapest["7891000100103"],
+		"products without offers count with their own price")
+}
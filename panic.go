@@ -0,0 +1,36 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError reports a scraper that panicked. The Engine recovers the panic so
+// it cannot crash the process, and reports the scraper as StatusPanicked.
+type PanicError struct {
+	// Value is the value passed to panic
+	Value any
+	// Stack is the stack trace of the panicking goroutine
+	Stack []byte
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", p.Value)
+}
+
+// Unwrap returns the panic value when it is an error, e.g. a runtime error
+func (p *PanicError) Unwrap() error {
+	err, _ := p.Value.(error)
+	return err
+}
+
+// safeScrape calls s, turning a panic into a PanicError
+func safeScrape(ctx context.Context, s Scraper, query string) (products []Product, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			products, err = nil, &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return s.Scrape(ctx, query)
+}
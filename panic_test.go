@@ -0,0 +1,86 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	core "achapromo.com/productscout"
+	"github.com/stretchr/testify/assert"
+)
+
+// panickingScraper dereferences a nil pointer, like a parser meeting an
+// unexpected answer
+type panickingScraper struct {
+	id    string
+	calls int
+}
+
+func (p *panickingScraper) Scrape(ctx context.Context, query string) ([]core.Product, error) {
+	p.calls++
+	var product *core.Product
+	return []core.Product{*product}, nil
+}
+
+func (p *panickingScraper) Info() core.Website {
+	return core.Website{ID: p.id}
+}
+
+func TestEngine_RecoversPanics(t *testing.T) {
+	engine := core.NewEngine(
+		core.WithLogger(core.NewLogger(discardHandler())),
+		core.WithDefaultScraperPolicy(core.ScraperPolicy{Retries: 2}),
+		core.WithScrapers(
+			&panickingScraper{id: "panicking.example"},
+			&fakeScraper{url: "https://ok.example", products: []core.Product{{Name: "Ok", GTIN: "7898215151784"}}},
+		),
+	)
+
+	result, err := engine.SearchWithReport(context.Background(), "7898215151784")
+
+	assert.NoError(t, err)
+	assert.Len(t, result.Products, 1)
+	assert.Equal(t, 1, result.Count(core.StatusPanicked))
+	assert.Equal(t, 1, result.Sources[0].Attempts, "panics are not retried")
+
+	var panicErr *core.PanicError
+	if assert.ErrorAs(t, result.Err(), &panicErr) {
+		assert.Contains(t, string(panicErr.Stack), "panickingScraper")
+		var runtimeErr runtime.Error
+		assert.True(t, errors.As(panicErr, &runtimeErr))
+	}
+}
+
+func TestEngine_PanicTripsBreaker(t *testing.T) {
+	engine := core.NewEngine(
+		core.WithLogger(core.NewLogger(discardHandler())),
+		core.WithCircuitBreaker(core.BreakerConfig{FailureThreshold: 5, OpenTimeout: time.Minute, TripOnPanic: true}),
+		core.WithScrapers(&panickingScraper{id: "panicking.example"}),
+	)
+
+	_, err := engine.Search("7898215151784")
+
+	assert.ErrorAs(t, err, new(*core.PanicError))
+	assert.Equal(t, core.BreakerOpen, engine.Breakers()["panicking.example"].State)
+}
+
+type panickingLegacyScraper struct{}
+
+func (panickingLegacyScraper) Scrape(query string) ([]core.Product, error) {
+	panic("unexpected answer")
+}
+
+func (panickingLegacyScraper) Info() core.Website {
+	return core.Website{ID: "legacy.example"}
+}
+
+func TestFromLegacy_RecoversPanics(t *testing.T) {
+	scraper := core.FromLegacy(panickingLegacyScraper{})
+
+	_, err := scraper.Scrape(context.Background(), "7898215151784")
+
+	assert.ErrorAs(t, err, new(*core.PanicError))
+	assert.EqualError(t, err, "panic: unexpected answer")
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ScraperPolicy bounds the time and the retries spent on a single scraper
+type ScraperPolicy struct {
+	// Timeout bounds each attempt. Zero means only the Engine timeout applies.
+	Timeout time.Duration
+	// Retries is how many times a failed attempt is repeated. Not found
+	// answers and cancellations are never retried.
+	Retries int
+	// Backoff is the pause before the first retry, doubled for each next one
+	Backoff time.Duration
+}
+
+// WithScraperPolicy sets the policy of the scraper whose Website has the
+// given ID, overriding the default policy
+func WithScraperPolicy(id string, p ScraperPolicy) Option {
+	return func(e *Engine) {
+		if e.config.Policies == nil {
+			e.config.Policies = map[string]ScraperPolicy{}
+		}
+		e.config.Policies[id] = p
+	}
+}
+
+// WithDefaultScraperPolicy sets the policy of scrapers without their own
+func WithDefaultScraperPolicy(p ScraperPolicy) Option {
+	return func(e *Engine) {
+		e.config.DefaultPolicy = p
+	}
+}
+
+func (e *Engine) policy(website Website) ScraperPolicy {
+	if p, ok := e.config.Policies[website.key()]; ok {
+		return p
+	}
+	return e.config.DefaultPolicy
+}
+
+// attempts runs the scraper as many times as its policy allows, recording the
+// number of attempts in report
+func (e *Engine) attempts(ctx context.Context, s Scraper, report *SourceReport, product string) ([]Product, error) {
+	policy := e.policy(report.Website)
+	backoff := policy.Backoff
+
+	for {
+		products, err := e.attempt(ctx, s, report.Website, product, policy.Timeout)
+		report.Attempts++
+
+		if !retryable(ctx, err) || report.Attempts > policy.Retries {
+			return products, err
+		}
+
+		LoggerFrom(ctx).Debug("retrying", "attempt", report.Attempts, "err", err)
+		wait := backoff
+		if after, ok := RetryAfter(err); ok && after > wait { // Honour the delay asked by a rate limited source
+			wait = after
+		}
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return products, err
+			}
+			backoff *= 2
+		}
+	}
+}
+
+// attempt queries the scraper once, trying each GTIN form it prefers until
+// one finds the product
+func (e *Engine) attempt(ctx context.Context, s Scraper, website Website, product string, timeout time.Duration) ([]Product, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var products []Product
+	var err error
+	limiter := e.limiter(website)
+	forms := queryForms(website, product)
+	for i, query := range forms {
+		if limiter != nil {
+			if err = limiter.wait(ctx); err != nil {
+				break
+			}
+		}
+		products, err = safeScrape(ctx, s, query)
+		notFound := errors.Is(err, ErrProductNotFound) || err == nil && len(products) == 0
+		if !notFound || i == len(forms)-1 {
+			break
+		}
+	}
+	return products, err
+}
+
+// retryable reports whether err deserves another attempt: it must be a real
+// failure that repeating may fix, and the search itself must still be running
+func retryable(ctx context.Context, err error) bool {
+	return err != nil &&
+		!errors.Is(err, ErrProductNotFound) &&
+		!errors.Is(err, ErrUnsupportedQuery) &&
+		!errors.Is(err, ErrBlocked) &&
+		!errors.Is(err, ErrUnauthorized) &&
+		!errors.Is(err, ErrSchemaChanged) &&
+		!errors.As(err, new(*PanicError)) &&
+		ctx.Err() == nil
+}
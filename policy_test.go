@@ -0,0 +1,77 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	core "achapromo.com/productscout"
+	"github.com/stretchr/testify/assert"
+)
+
+type flakyScraper struct {
+	id       string
+	failures int
+	calls    int
+}
+
+func (f *flakyScraper) Scrape(ctx context.Context, query string) ([]core.Product, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, errors.New("upstream hiccup")
+	}
+	return []core.Product{{Name: "Recovered", GTIN: query}}, nil
+}
+
+func (f *flakyScraper) Info() core.Website {
+	return core.Website{ID: f.id}
+}
+
+func TestEngine_ScraperPolicy_Retries(t *testing.T) {
+	flaky := &flakyScraper{id: "flaky.example", failures: 2}
+	engine := core.NewEngine(
+		core.WithScraperPolicy("flaky.example", core.ScraperPolicy{Retries: 2, Backoff: time.Millisecond}),
+		core.WithScrapers(flaky),
+	)
+
+	result, err := engine.SearchWithReport(context.Background(), "7898215151784")
+
+	assert.NoError(t, err)
+	assert.Equal(t, core.StatusOK, result.Sources[0].Status)
+	assert.Equal(t, 3, result.Sources[0].Attempts)
+	assert.Len(t, result.Products, 1)
+}
+
+func TestEngine_ScraperPolicy_RetriesExhausted(t *testing.T) {
+	flaky := &flakyScraper{id: "flaky.example", failures: 5}
+	engine := core.NewEngine(
+		core.WithDefaultScraperPolicy(core.ScraperPolicy{Retries: 1}),
+		core.WithScrapers(flaky),
+	)
+
+	result, err := engine.SearchWithReport(context.Background(), "7898215151784")
+
+	assert.Error(t, err)
+	assert.Equal(t, core.StatusError, result.Sources[0].Status)
+	assert.Equal(t, 2, result.Sources[0].Attempts)
+	assert.Equal(t, 2, flaky.calls)
+}
+
+func TestEngine_ScraperPolicy_Timeout(t *testing.T) {
+	engine := core.NewEngine(
+		core.WithScraperPolicy("https://slow.example", core.ScraperPolicy{Timeout: 20 * time.Millisecond}),
+		core.WithScrapers(
+			&fakeScraper{url: "https://slow.example", delay: time.Minute},
+			&fakeScraper{url: "https://patient.example", delay: 50 * time.Millisecond, products: []core.Product{{Name: "Patient", GTIN: "7898215151784"}}},
+		),
+	)
+
+	start := time.Now()
+	result, err := engine.SearchWithReport(context.Background(), "7898215151784")
+
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, core.StatusTimedOut, result.Sources[0].Status)
+	assert.Equal(t, core.StatusOK, result.Sources[1].Status)
+}
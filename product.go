@@ -0,0 +1,37 @@
+package core
+
+import "slices"
+
+// Availability tells whether a source can sell the product right now
+type Availability string
+
+const (
+	AvailabilityUnknown    Availability = ""
+	AvailabilityInStock    Availability = "in_stock"
+	AvailabilityOutOfStock Availability = "out_of_stock"
+)
+
+// fillMissing copies into p the descriptive fields it lacks from other.
+// Store-specific fields, like prices and availability, are left untouched.
+func (p *Product) fillMissing(other Product) {
+	if p.Name == "" {
+		p.Name = other.Name
+	}
+	if p.Brand == "" {
+		p.Brand = other.Brand
+	}
+	if p.Quantity == "" {
+		p.Quantity = other.Quantity
+	}
+	p.Images = appendUnique(p.Images, other.Images...)
+	p.Categories = appendUnique(p.Categories, other.Categories...)
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		if value != "" && !slices.Contains(list, value) {
+			list = append(list, value)
+		}
+	}
+	return list
+}
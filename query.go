@@ -0,0 +1,85 @@
+package core
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// QueryType tells whether a query is a barcode or free text
+type QueryType string
+
+const (
+	QueryGTIN QueryType = "gtin"
+	QueryText QueryType = "text"
+)
+
+var ErrUnsupportedQuery = errors.New("query type not supported by the website")
+
+// QueryTypeOf classifies query. Anything made only of digits is treated as a
+// barcode, even with a wrong check digit, so it never reaches text search.
+func QueryTypeOf(query string) QueryType {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return QueryText
+	}
+	for _, r := range query {
+		if r < '0' || r > '9' {
+			return QueryText
+		}
+	}
+	return QueryGTIN
+}
+
+// Supports reports whether the website can answer queries of type t. Websites
+// that do not declare QueryTypes only support barcode lookups.
+func (w Website) Supports(t QueryType) bool {
+	if len(w.QueryTypes) == 0 {
+		return t == QueryGTIN
+	}
+	return slices.Contains(w.QueryTypes, t)
+}
+
+// rankByText orders products by how well their names match a free-text
+// query: first by the share of query words found in the name, then by the
+// overall similarity. Barcode queries are left in their original order.
+func rankByText(query string, products []Product) {
+	if QueryTypeOf(query) != QueryText {
+		return
+	}
+
+	type ranked struct {
+		product    Product
+		coverage   float64
+		similarity float64
+	}
+
+	queryTokens := tokenize(query)
+	rankings := make([]ranked, len(products))
+	for i, product := range products {
+		nameTokens := tokenize(product.Name)
+		matched := 0
+		for _, token := range queryTokens {
+			if slices.Contains(nameTokens, token) {
+				matched++
+			}
+		}
+
+		rankings[i] = ranked{product: product, similarity: similarity(queryTokens, nameTokens)}
+		if len(queryTokens) > 0 {
+			rankings[i].coverage = float64(matched) / float64(len(queryTokens))
+		}
+	}
+
+	slices.SortStableFunc(rankings, func(a, b ranked) int {
+		if c := cmp.Compare(b.coverage, a.coverage); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.similarity, a.similarity)
+	})
+
+	for i := range rankings {
+		products[i] = rankings[i].product
+	}
+}
@@ -0,0 +1,108 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"achapromo.com/productscout/httpclient"
+	"achapromo.com/productscout/metrics"
+)
+
+type (
+	// Settings configures a scraper built through the registry. Zero values
+	// keep the scraper defaults.
+	Settings struct {
+		BaseURL   string            `json:"base_url" yaml:"base_url"`
+		Headers   map[string]string `json:"headers" yaml:"headers"`
+		APIKey    string            `json:"api_key" yaml:"api_key"`
+		UserAgent string            `json:"user_agent" yaml:"user_agent"`
+		Timeout   Duration          `json:"timeout" yaml:"timeout"`
+		// Metrics, when set, records the HTTP requests of the scraper.
+		// NewEngineFromConfig fills it from WithMetrics.
+		Metrics *metrics.Registry `json:"-" yaml:"-"`
+	}
+
+	// Factory builds a scraper from its settings
+	Factory func(Settings) (Scraper, error)
+)
+
+var (
+	ErrUnknownScraper = errors.New("unknown scraper")
+
+	registry      = map[string]Factory{}
+	registryMutex sync.RWMutex
+)
+
+// Register makes a scraper available under a stable id, usually from the init
+// function of its package. It panics if id is registered twice.
+func Register(id string, factory Factory) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	if factory == nil {
+		panic("core: Register factory is nil for " + id)
+	}
+	if _, dup := registry[id]; dup {
+		panic("core: Register called twice for " + id)
+	}
+	registry[id] = factory
+}
+
+// Registered returns the sorted ids of every registered scraper
+func Registered() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	ids := make([]string, 0, len(registry))
+	for id := range registry {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// NewScraper builds the scraper registered under id
+func NewScraper(id string, settings Settings) (Scraper, error) {
+	registryMutex.RLock()
+	factory, ok := registry[id]
+	registryMutex.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownScraper, id)
+	}
+	return factory(settings)
+}
+
+// HttpClient builds an HttpClient honouring the timeout and user agent of the
+// settings
+func (s Settings) HttpClient() *httpclient.HttpClient {
+	opts := []httpclient.Option{httpclient.WithUserAgent(s.UserAgent)}
+	if s.Timeout > 0 {
+		opts = append(opts, httpclient.WithTimeout(time.Duration(s.Timeout)))
+	}
+	if s.Metrics != nil {
+		opts = append(opts, httpclient.WithMetrics(s.Metrics))
+	}
+	return httpclient.NewHttpClient(opts...)
+}
+
+// MergeHeaders returns defaults overridden by UserAgent, when set, then by
+// the configured headers. Keys are canonicalized, so "user-agent" replaces a
+// default "User-Agent" instead of racing with it.
+func (s Settings) MergeHeaders(defaults map[string]string) map[string]string {
+	headers := make(map[string]string, len(defaults)+len(s.Headers)+1)
+	for key, value := range defaults {
+		headers[http.CanonicalHeaderKey(key)] = value
+	}
+	if s.UserAgent != "" {
+		headers["User-Agent"] = s.UserAgent
+	}
+	for key, value := range s.Headers {
+		headers[http.CanonicalHeaderKey(key)] = value
+	}
+	return headers
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type (
+	// Status tells how a single scraper ended during a search
+	Status string
+
+	// SourceReport describes the outcome of one scraper for one search
+	SourceReport struct {
+		Website  Website
+		Status   Status
+		Latency  time.Duration
+		Attempts int
+		Count    int
+		Err      error
+		// Cached is set when the answer came from the Engine cache, which got
+		// it from the scraper at CachedAt. Its prices are as old as that.
+		Cached   bool
+		CachedAt time.Time
+		// Coalesced is set when the answer was shared with a concurrent
+		// identical query
+		Coalesced bool
+	}
+
+	// SearchResult holds the products found by a search and one SourceReport
+	// per scraper, in the order the scrapers were registered
+	SearchResult struct {
+		Query    string
+		Products []Product
+		Sources  []SourceReport
+	}
+
+	// SearchEvent is emitted by Engine.Stream. There is one event per scraper
+	// with its report and products, then a final event with Done set that
+	// carries the whole SearchResult and the search error, if any.
+	SearchEvent struct {
+		Source   SourceReport
+		Products []Product
+
+		Done   bool
+		Result *SearchResult
+		Err    error
+	}
+)
+
+const (
+	StatusOK       Status = "ok"
+	StatusNotFound Status = "not_found"
+	StatusError    Status = "error"
+	StatusSkipped  Status = "skipped"
+	StatusTimedOut Status = "timed_out"
+	StatusCanceled Status = "canceled"
+	StatusPanicked Status = "panicked"
+)
+
+// Count returns how many sources ended with the given status
+func (r *SearchResult) Count(status Status) int {
+	n := 0
+	for _, source := range r.Sources {
+		if source.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed reports whether no source answered, either with products or with a
+// definitive not found
+func (r *SearchResult) Failed() bool {
+	return r.Count(StatusOK)+r.Count(StatusNotFound) == 0
+}
+
+// String summarizes the outcome, like "ok: 3 items" or "skipped: circuit open"
+func (s SourceReport) String() string {
+	switch {
+	case s.Status == StatusOK:
+		return fmt.Sprintf("%s: %d items", s.Status, s.Count)
+	case s.Err != nil:
+		return fmt.Sprintf("%s: %v", s.Status, s.Err)
+	default:
+		return string(s.Status)
+	}
+}
+
+func statusOf(err error) Status {
+	switch {
+	case err == nil:
+		return StatusOK
+	case errors.Is(err, ErrProductNotFound):
+		return StatusNotFound
+	case errors.As(err, new(*PanicError)):
+		return StatusPanicked
+	case errors.Is(err, context.DeadlineExceeded):
+		return StatusTimedOut
+	case errors.Is(err, context.Canceled):
+		return StatusCanceled
+	default:
+		return StatusError
+	}
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"context"
+	"runtime/debug"
+)
+
+type (
+	// LegacyScraper is the scraper contract used before Scrape received a
+	// context. Wrap implementations with FromLegacy until they are migrated.
+	LegacyScraper interface {
+		Scrape(product string) ([]Product, error)
+		Info() Website
+	}
+
+	legacyScraper struct {
+		scraper LegacyScraper
+	}
+
+	legacyResult struct {
+		products []Product
+		err      error
+	}
+)
+
+// FromLegacy adapts a LegacyScraper to the Scraper interface. The legacy call
+// cannot be interrupted, so on cancellation the adapter returns ctx.Err() right
+// away and lets the call finish in the background.
+func FromLegacy(s LegacyScraper) Scraper {
+	return &legacyScraper{scraper: s}
+}
+
+func (l *legacyScraper) Scrape(ctx context.Context, query string) ([]Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	done := make(chan legacyResult, 1) // Buffered so the goroutine never blocks after a cancellation
+	go func() {
+		defer func() {
+			if r := recover(); r != nil { // Nothing upstream can recover a panic of this goroutine
+				done <- legacyResult{err: &PanicError{Value: r, Stack: debug.Stack()}}
+			}
+		}()
+		products, err := l.scraper.Scrape(query)
+		done <- legacyResult{products: products, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.products, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (l *legacyScraper) Info() Website {
+	return l.scraper.Info()
+}
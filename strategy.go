@@ -0,0 +1,79 @@
+package core
+
+import "context"
+
+// Strategy decides, each time a scraper answers, whether the products found
+// so far are enough to finish the search. The scrapers still running are then
+// cancelled and the ones not started yet are skipped with ErrNotNeeded.
+// Hedged requests, which race a backup call against a slow scraper, are the
+// Hedge middleware.
+type Strategy func(products []Product) bool
+
+// WithStrategy sets the completion strategy of the searches. It applies to
+// Search, SearchWithReport and Stream; batches always wait for every item.
+func WithStrategy(s Strategy) Option {
+	return func(e *Engine) {
+		e.config.Strategy = s
+	}
+}
+
+// WaitAll waits for every scraper to answer. It is the default strategy.
+func WaitAll() Strategy {
+	return func([]Product) bool { return false }
+}
+
+// FirstSuccess finishes as soon as any scraper finds a product
+func FirstSuccess() Strategy {
+	return FirstN(1)
+}
+
+// FirstN finishes as soon as n products are found
+func FirstN(n int) Strategy {
+	return func(products []Product) bool {
+		return len(products) >= n
+	}
+}
+
+// Quorum finishes as soon as k different sources found products whose names
+// agree, that is share at least half of their words
+func Quorum(k int) Strategy {
+	return func(products []Product) bool {
+		tokens := make([][]string, len(products))
+		for i, product := range products {
+			tokens[i] = tokenize(product.Name)
+		}
+
+		for i := range products {
+			sources := map[string]bool{}
+			for j := range products {
+				if i == j || similarity(tokens[i], tokens[j]) >= 0.5 {
+					sources[products[j].Source.key()] = true
+				}
+			}
+			if len(sources) >= k {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// completion tracks the products of a search against its strategy and
+// cancels the search once it is satisfied
+type completion struct {
+	strategy  Strategy
+	cancel    context.CancelFunc
+	products  []Product
+	satisfied bool
+}
+
+// add records the products of a scraper and reports whether the strategy is
+// satisfied. Callers must serialize calls.
+func (c *completion) add(products []Product) bool {
+	c.products = append(c.products, products...)
+	if !c.satisfied && c.strategy != nil && c.strategy(c.products) {
+		c.satisfied = true
+		c.cancel()
+	}
+	return c.satisfied
+}
@@ -0,0 +1,96 @@
+package core_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	core "achapromo.com/productscout"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngine_Strategy_FirstSuccessCancelsTheRest(t *testing.T) {
+	engine := core.NewEngine(
+		core.WithMaxConcurrency(2),
+		core.WithStrategy(core.FirstSuccess()),
+		core.WithScrapers(
+			&fakeScraper{url: "https://fast.example", delay: 20 * time.Millisecond, products: []core.Product{{Name: "Fast", GTIN: "7898215151784"}}},
+			&fakeScraper{url: "https://slow.example", delay: time.Minute},
+			&fakeScraper{url: "https://queued.example", delay: time.Minute},
+		),
+	)
+
+	start := time.Now()
+	result, err := engine.SearchWithReport(context.Background(), "7898215151784")
+
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Len(t, result.Products, 1)
+	if assert.Len(t, result.Sources, 3) {
+		assert.Equal(t, core.StatusOK, result.Sources[0].Status)
+		assert.Equal(t, core.StatusCanceled, result.Sources[1].Status)
+		assert.Equal(t, core.StatusSkipped, result.Sources[2].Status)
+		assert.ErrorIs(t, result.Sources[2].Err, core.ErrNotNeeded)
+	}
+}
+
+func TestEngine_Strategy_WaitAll(t *testing.T) {
+	engine := core.NewEngine(
+		core.WithStrategy(core.WaitAll()),
+		core.WithScrapers(
+			&fakeScraper{url: "https://fast.example", products: []core.Product{{Name: "Fast", GTIN: "7898215151784"}}},
+			&fakeScraper{url: "https://slow.example", delay: 20 * time.Millisecond, products: []core.Product{{Name: "Slow", GTIN: "7898215151784"}}},
+		),
+	)
+
+	result, err := engine.SearchWithReport(context.Background(), "7898215151784")
+
+	assert.NoError(t, err)
+	assert.Len(t, result.Products, 2)
+	assert.Equal(t, 2, result.Count(core.StatusOK))
+}
+
+func TestFirstN(t *testing.T) {
+	strategy := core.FirstN(2)
+
+	assert.False(t, strategy([]core.Product{{Name: "A"}}))
+	assert.True(t, strategy([]core.Product{{Name: "A"}, {Name: "B"}}))
+}
+
+func TestQuorum(t *testing.T) {
+	a := core.Website{URL: "https://a.example"}
+	b := core.Website{URL: "https://b.example"}
+	strategy := core.Quorum(2)
+
+	assert.False(t, strategy([]core.Product{
+		{Name: "Creme de Leite Piracanjuba 200g", Source: a},
+		{Name: "Creme de Leite Piracanjuba Caixinha 200g", Source: a},
+	}), "one source cannot reach a quorum alone")
+	assert.False(t, strategy([]core.Product{
+		{Name: "Creme de Leite Piracanjuba 200g", Source: a},
+		{Name: "Sabão em Pó Omo 1kg", Source: b},
+	}))
+	assert.True(t, strategy([]core.Product{
+		{Name: "Creme de Leite Piracanjuba 200g", Source: a},
+		{Name: "CREME DE LEITE PIRACANJUBA CAIXINHA 200G", Source: b},
+	}))
+}
+
+func TestEngine_Strategy_Quorum(t *testing.T) {
+	engine := core.NewEngine(
+		core.WithStrategy(core.Quorum(2)),
+		core.WithScrapers(
+			&fakeScraper{url: "https://a.example", products: []core.Product{{Name: "Creme de Leite Piracanjuba 200g", GTIN: "7898215151784"}}},
+			&fakeScraper{url: "https://b.example", delay: 10 * time.Millisecond, products: []core.Product{{Name: "Creme de Leite Piracanjuba", GTIN: "7898215151784"}}},
+			&fakeScraper{url: "https://slow.example", delay: time.Minute},
+		),
+	)
+
+	start := time.Now()
+	result, err := engine.SearchWithReport(context.Background(), "7898215151784")
+
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Len(t, result.Products, 2)
+	assert.Equal(t, core.StatusCanceled, result.Sources[2].Status)
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"errors"
+
+	"achapromo.com/productscout/gtin"
+)
+
+// StopCondition decides, after a tier has answered, whether the products
+// found so far are enough to skip the next tiers
+type StopCondition func(products []Product) bool
+
+// ErrNotNeeded reports a scraper skipped because the search was already
+// answered, by an earlier tier or by the completion strategy
+var ErrNotNeeded = errors.New("not needed: search already answered")
+
+// WithTiers splits the scrapers into ordered tiers, each listing Website IDs.
+// A search queries one tier at a time and stops as soon as the StopCondition
+// holds, so later tiers are only used as fallback. Scrapers not listed form
+// an implicit last tier.
+func WithTiers(tiers ...[]string) Option {
+	return func(e *Engine) {
+		e.config.Tiers = tiers
+	}
+}
+
+// WithStopCondition sets when a tiered search stops. It defaults to
+// HasIdentifiedProduct.
+func WithStopCondition(stop StopCondition) Option {
+	return func(e *Engine) {
+		e.config.Stop = stop
+	}
+}
+
+// HasIdentifiedProduct holds when a product has a name and a valid GTIN
+func HasIdentifiedProduct(products []Product) bool {
+	for _, product := range products {
+		if product.Name != "" && gtin.Valid(product.GTIN) {
+			return true
+		}
+	}
+	return false
+}
+
+// tiers groups scraper indexes by tier. Without configured tiers every
+// scraper belongs to a single tier.
+func (e *Engine) tiers() [][]int {
+	if len(e.config.Tiers) == 0 {
+		all := make([]int, len(e.scrapers))
+		for i := range all {
+			all[i] = i
+		}
+		return [][]int{all}
+	}
+
+	tierOf := map[string]int{}
+	for t, ids := range e.config.Tiers {
+		for _, id := range ids {
+			if _, ok := tierOf[id]; !ok {
+				tierOf[id] = t
+			}
+		}
+	}
+
+	tiers := make([][]int, len(e.config.Tiers)+1)
+	for idx, scraper := range e.scrapers {
+		t, ok := tierOf[scraper.Info().key()]
+		if !ok {
+			t = len(e.config.Tiers)
+		}
+		tiers[t] = append(tiers[t], idx)
+	}
+
+	nonEmpty := tiers[:0]
+	for _, tier := range tiers {
+		if len(tier) > 0 {
+			nonEmpty = append(nonEmpty, tier)
+		}
+	}
+	return nonEmpty
+}
+
+func (e *Engine) stop(products []Product) bool {
+	if e.config.Stop != nil {
+		return e.config.Stop(products)
+	}
+	return HasIdentifiedProduct(products)
+}
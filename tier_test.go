@@ -0,0 +1,67 @@
+package core_test
+
+import (
+	"context"
+	"testing"
+
+	core "achapromo.com/productscout"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngine_Tiers_StopAfterFirstTier(t *testing.T) {
+	retail := &flakyScraper{id: "retail.example"}
+	engine := core.NewEngine(
+		core.WithTiers([]string{"https://open.example"}, []string{"retail.example"}),
+		core.WithScrapers(
+			retail,
+			&fakeScraper{url: "https://open.example", products: []core.Product{{Name: "Creme de Leite", GTIN: "7898215151784"}}},
+		),
+	)
+
+	result, err := engine.SearchWithReport(context.Background(), "7898215151784")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, retail.calls)
+	assert.Equal(t, core.StatusSkipped, result.Sources[0].Status)
+	assert.ErrorIs(t, result.Sources[0].Err, core.ErrNotNeeded)
+	assert.Equal(t, core.StatusOK, result.Sources[1].Status)
+	assert.Len(t, result.Products, 1)
+}
+
+func TestEngine_Tiers_FallBack(t *testing.T) {
+	retail := &flakyScraper{id: "retail.example"}
+	engine := core.NewEngine(
+		core.WithTiers([]string{"https://open.example"}),
+		core.WithScrapers(
+			&fakeScraper{url: "https://open.example", err: core.ErrProductNotFound},
+			retail,
+		),
+	)
+
+	result, err := engine.SearchWithReport(context.Background(), "7898215151784")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, retail.calls)
+	assert.Equal(t, core.StatusNotFound, result.Sources[0].Status)
+	assert.Equal(t, core.StatusOK, result.Sources[1].Status)
+}
+
+func TestEngine_Tiers_Batch(t *testing.T) {
+	retail := &flakyScraper{id: "retail.example"}
+	engine := core.NewEngine(
+		core.WithMaxConcurrency(1),
+		core.WithTiers([]string{"https://indexed.example"}),
+		core.WithStopCondition(func(products []core.Product) bool { return len(products) > 0 }),
+		core.WithScrapers(
+			&indexedScraper{products: map[string]core.Product{"7898215151784": {Name: "Known", GTIN: "7898215151784"}}},
+			retail,
+		),
+	)
+
+	results, err := engine.SearchBatch(context.Background(), []string{"7898215151784", "7898422745523"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, retail.calls)
+	assert.ErrorIs(t, results["7898215151784"].Sources[1].Err, core.ErrNotNeeded)
+	assert.Equal(t, core.StatusOK, results["7898422745523"].Sources[1].Status)
+}
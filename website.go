@@ -0,0 +1,174 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"achapromo.com/productscout/gtin"
+)
+
+type (
+	// Website describes a source and what it can answer. The Engine uses it to
+	// route queries, pace requests and label results.
+	Website struct {
+		// ID is a stable identifier, conventionally the site domain, like
+		// "openfoodfacts.org"
+		ID   string
+		Name string
+		URL  string
+
+		// Country is the ISO 3166-1 alpha-2 code of the market the website
+		// sells to. Empty means worldwide.
+		Country  string
+		Vertical Vertical
+
+		// QueryTypes lists the kinds of query the website answers. Empty means
+		// barcode lookups only.
+		QueryTypes []QueryType
+
+		// GTINFormats lists the barcode representations the website indexes
+		// products by, in order of preference. The Engine tries each one until
+		// the product is found. Empty means the query is sent as given.
+		GTINFormats []gtin.Format
+
+		// RateLimit is the pace the website tolerates. The Engine spaces out
+		// the requests of all searches accordingly.
+		RateLimit RateLimit
+	}
+
+	// Vertical is the kind of business behind a website
+	Vertical string
+
+	// RateLimit allows Requests every Per. The zero value means no limit.
+	RateLimit struct {
+		Requests int
+		Per      time.Duration
+	}
+
+	// rateLimiter spaces out requests evenly according to a RateLimit
+	rateLimiter struct {
+		mu       sync.Mutex
+		interval time.Duration
+		next     time.Time
+	}
+)
+
+const (
+	VerticalOpenData    Vertical = "open_data"
+	VerticalPharmacy    Vertical = "pharmacy"
+	VerticalFoodservice Vertical = "foodservice"
+	VerticalGrocery     Vertical = "grocery"
+)
+
+// String returns the most human-friendly label available for the website
+func (w Website) String() string {
+	switch {
+	case w.Name != "":
+		return w.Name
+	case w.ID != "":
+		return w.ID
+	default:
+		return w.URL
+	}
+}
+
+// key identifies the website inside the Engine
+func (w Website) key() string {
+	if w.ID != "" {
+		return w.ID
+	}
+	return w.URL
+}
+
+// Sources returns the metadata of every scraper of the Engine
+func (e *Engine) Sources() []Website {
+	sources := make([]Website, len(e.scrapers))
+	for i, scraper := range e.scrapers {
+		sources[i] = scraper.Info()
+	}
+	return sources
+}
+
+// WriteCatalog renders sources as an aligned text table
+func WriteCatalog(w io.Writer, sources []Website) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tCOUNTRY\tVERTICAL\tQUERIES\tRATE LIMIT\tURL")
+	for _, source := range sources {
+		queries := []string{}
+		for _, t := range source.QueryTypes {
+			queries = append(queries, string(t))
+		}
+		if len(queries) == 0 {
+			queries = append(queries, string(QueryGTIN))
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			dash(source.ID), dash(source.Name), dash(source.Country), dash(string(source.Vertical)),
+			strings.Join(queries, ","), dash(source.RateLimit.String()), source.URL)
+	}
+	return tw.Flush()
+}
+
+func (r RateLimit) String() string {
+	if r.Requests <= 0 || r.Per <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d/%s", r.Requests, r.Per)
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// limiter returns the shared rate limiter of website, or nil when it has no
+// rate limit
+func (e *Engine) limiter(website Website) *rateLimiter {
+	if website.RateLimit.Requests <= 0 || website.RateLimit.Per <= 0 {
+		return nil
+	}
+
+	e.limitersMutex.Lock()
+	defer e.limitersMutex.Unlock()
+
+	if e.limiters == nil {
+		e.limiters = map[string]*rateLimiter{}
+	}
+	l, ok := e.limiters[website.key()]
+	if !ok {
+		l = &rateLimiter{interval: website.RateLimit.Per / time.Duration(website.RateLimit.Requests)}
+		e.limiters[website.key()] = l
+	}
+	return l
+}
+
+// wait blocks until the next request slot or until ctx is done. The slot is
+// only taken once it is due, so callers giving up never delay the others.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		if !l.next.After(now) {
+			l.next = now.Add(l.interval)
+			l.mu.Unlock()
+			return nil
+		}
+		delay := l.next.Sub(now)
+		l.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package core_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	core "achapromo.com/productscout"
+	"github.com/stretchr/testify/assert"
+)
+
+type pacedScraper struct {
+	calls []time.Time
+}
+
+func (p *pacedScraper) Scrape(ctx context.Context, query string) ([]core.Product, error) {
+	p.calls = append(p.calls, time.Now())
+	return nil, core.ErrProductNotFound
+}
+
+func (p *pacedScraper) Info() core.Website {
+	return core.Website{ID: "paced.example", RateLimit: core.RateLimit{Requests: 1, Per: 30 * time.Millisecond}}
+}
+
+func TestEngine_RateLimit(t *testing.T) {
+	scraper := &pacedScraper{}
+	engine := core.NewEngine(core.WithScrapers(scraper))
+
+	for i := 0; i < 3; i++ {
+		_, err := engine.Search("7898215151784")
+		assert.NoError(t, err)
+	}
+
+	if assert.Len(t, scraper.calls, 3) {
+		assert.GreaterOrEqual(t, scraper.calls[2].Sub(scraper.calls[0]), 55*time.Millisecond)
+	}
+}
+
+func TestEngine_RateLimit_CancelledCallersFreeTheirSlot(t *testing.T) {
+	scraper := &pacedScraper{}
+	engine := core.NewEngine(core.WithScrapers(scraper))
+
+	_, err := engine.Search("7898215151784")
+	assert.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		_, _ = engine.SearchContext(ctx, "7898215151784")
+		cancel()
+	}
+	_, err = engine.Search("7898215151784")
+	assert.NoError(t, err)
+
+	if assert.Len(t, scraper.calls, 2) {
+		assert.Less(t, scraper.calls[1].Sub(scraper.calls[0]), 90*time.Millisecond, "the given up slots are not kept")
+	}
+}
+
+func TestWriteCatalog(t *testing.T) {
+	engine := core.NewEngine(core.WithScrapers(
+		&fakeScraper{url: "https://text.example", queryTypes: []core.QueryType{core.QueryGTIN, core.QueryText}},
+		&pacedScraper{},
+	))
+
+	var buf bytes.Buffer
+	err := core.WriteCatalog(&buf, engine.Sources())
+
+	assert.NoError(t, err)
+	assert.Equal(t, ""+
+		"ID             NAME  COUNTRY  VERTICAL  QUERIES    RATE LIMIT  URL\n"+
+		"-              -     -        -         gtin,text  -           https://text.example\n"+
+		"paced.example  -     -        -         gtin       1/30ms      \n", buf.String())
+}
+
+func TestWebsite_String(t *testing.T) {
+	assert.Equal(t, "Open Food Facts", core.Website{ID: "openfoodfacts.org", Name: "Open Food Facts"}.String())
+	assert.Equal(t, "openfoodfacts.org", core.Website{ID: "openfoodfacts.org", URL: "https://world.openfoodfacts.org"}.String())
+	assert.Equal(t, "https://world.openfoodfacts.org", core.Website{URL: "https://world.openfoodfacts.org"}.String())
+}
@@ -0,0 +1,12 @@
+// Package all registers every scraper of the websites directory. Import it for
+// its side effects to build engines from config files:
+//
+//	import _ "achapromo.com/productscout/websites/all"
+package all
+
+import (
+	_ "achapromo.com/productscout/websites/barcode.monster"
+	_ "achapromo.com/productscout/websites/comprafoodservice.com.br"
+	_ "achapromo.com/productscout/websites/openfoodfacts.org"
+	_ "achapromo.com/productscout/websites/paguemenos.com.br"
+)
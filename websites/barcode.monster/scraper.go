@@ -1,20 +1,29 @@
 package barcodemonster
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
 	core "achapromo.com/productscout"
+	"achapromo.com/productscout/gtin"
 	"achapromo.com/productscout/httpclient"
 )
 
 const (
-	URL = "https://barcode.monster"
+	ID   = "barcode.monster"
+	Name = "Barcode Monster"
+	URL  = "https://barcode.monster"
 )
 
 type (
 	Scraper struct {
 		HttpClient *httpclient.HttpClient
+
+		// BaseURL overrides URL, e.g. to point at a mirror or a test server
+		BaseURL string
+		// Headers are sent with every request, overriding the defaults
+		Headers map[string]string
 	}
 
 	ProductData struct {
@@ -27,8 +36,21 @@ type (
 	}
 )
 
-func (s *Scraper) Scrape(gtin string) ([]core.Product, error) {
-	productData, err := s.fetchProductData(gtin)
+func init() {
+	core.Register(ID, New)
+}
+
+// New builds a Scraper from registry settings
+func New(settings core.Settings) (core.Scraper, error) {
+	return &Scraper{
+		HttpClient: settings.HttpClient(),
+		BaseURL:    settings.BaseURL,
+		Headers:    settings.MergeHeaders(nil),
+	}, nil
+}
+
+func (s *Scraper) Scrape(ctx context.Context, query string) ([]core.Product, error) {
+	productData, err := s.fetchProductData(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -36,18 +58,31 @@ func (s *Scraper) Scrape(gtin string) ([]core.Product, error) {
 	if productData.Status == "not found" {
 		return nil, core.ErrProductNotFound
 	}
+	if productData.Code == "" {
+		return nil, fmt.Errorf("%w: answer without a code", core.ErrSchemaChanged)
+	}
 
 	product := core.Product{
-		Name: sanitize(productData.Description),
-		GTIN: productData.Code,
-		URL:  url(gtin),
+		Name:     sanitize(productData.Description),
+		GTIN:     productData.Code,
+		URL:      s.url(query),
+		Quantity: productData.Size,
+	}
+	if productData.ImageURL != "" {
+		product.Images = []string{productData.ImageURL}
 	}
 
 	return []core.Product{product}, nil
 }
 
 func (s *Scraper) Info() core.Website {
-	return core.Website{URL: URL}
+	return core.Website{
+		ID:          ID,
+		Name:        Name,
+		URL:         URL,
+		Vertical:    core.VerticalOpenData,
+		GTINFormats: []gtin.Format{gtin.EAN13, gtin.UPCA},
+	}
 }
 
 func sanitize(name string) string {
@@ -57,15 +92,22 @@ func sanitize(name string) string {
 	return name
 }
 
-func (s *Scraper) fetchProductData(gtin string) (*ProductData, error) {
+func (s *Scraper) fetchProductData(ctx context.Context, query string) (*ProductData, error) {
 	var productData ProductData
-	err := s.HttpClient.GetJSON(url(gtin), &productData)
+	err := s.HttpClient.GetJSON(ctx, s.url(query), &productData, httpclient.Headers(s.Headers))
 	if err != nil {
 		return nil, err
 	}
 	return &productData, nil
 }
 
-func url(gtin string) string {
-	return fmt.Sprintf("%s/api/%s", URL, gtin)
+func (s *Scraper) url(query string) string {
+	return fmt.Sprintf("%s/api/%s", s.baseURL(), query)
+}
+
+func (s *Scraper) baseURL() string {
+	if s.BaseURL != "" {
+		return s.BaseURL
+	}
+	return URL
 }
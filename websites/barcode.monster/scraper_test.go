@@ -1,6 +1,7 @@
 package barcodemonster_test
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -26,7 +27,7 @@ func TestScraper_ScrapeProductData_Valid(t *testing.T) {
 	scraper := barcodemonster.Scraper{HttpClient: httpclient.NewHttpClient()}
 
 	// Call
-	productData, err := scraper.Scrape("7898422745523")
+	productData, err := scraper.Scrape(context.Background(), "7898422745523")
 
 	// Verify
 	assert.NoError(t, err)
@@ -9,19 +9,44 @@ curl 'https://api.linximpulse.com/engage/search/v3/search?apiKey=cfs-new&page=1&
 */
 
 import (
+	"context"
 	"fmt"
+	neturl "net/url"
+	"strings"
+	"time"
 
 	core "achapromo.com/productscout"
+	"achapromo.com/productscout/gtin"
 	"achapromo.com/productscout/httpclient"
 )
 
 const (
-	URL = "https://api.linximpulse.com"
+	ID   = "comprafoodservice.com.br"
+	Name = "Compra Food Service"
+	URL  = "https://api.linximpulse.com"
+
+	currency = "BRL"
+	apiKey   = "cfs-new"
 )
 
+var defaultHeaders = map[string]string{
+	"authority":  "api.linximpulse.com",
+	"origin":     "https://www.comprafoodservice.com.br",
+	"referer":    "https://www.comprafoodservice.com.br/buscar/",
+	"user-agent": "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0.0.0 Safari/537.36",
+}
+
 type (
 	Scraper struct {
 		HttpClient *httpclient.HttpClient
+
+		// BaseURL overrides URL, e.g. to point at a mirror or a test server
+		BaseURL string
+		// Headers are sent with every request; New fills them with the
+		// defaults overridden by the configured ones, and nil sends the defaults
+		Headers map[string]string
+		// APIKey overrides the public Linx API key of the store
+		APIKey string
 	}
 
 	ProductData struct {
@@ -58,10 +83,10 @@ type (
 				ProductId string        `json:"productId"`
 				SkuList   []interface{} `json:"skuList"`
 			} `json:"collectInfo"`
-			ClickUrl string `json:"clickUrl"`
-			Name     string `json:"name"`
-			Price    int    `json:"price"`
-			OldPrice int    `json:"oldPrice"`
+			ClickUrl string  `json:"clickUrl"`
+			Name     string  `json:"name"`
+			Price    float64 `json:"price"`
+			OldPrice float64 `json:"oldPrice"`
 
 			Url    string `json:"url"`
 			Images struct {
@@ -106,9 +131,9 @@ type (
 					Caixa string `json:"caixa"`
 				} `json:"specs"`
 				Properties struct {
-					Status   string `json:"status"`
-					Price    int    `json:"price"`
-					OldPrice int    `json:"oldPrice"`
+					Status   string  `json:"status"`
+					Price    float64 `json:"price"`
+					OldPrice float64 `json:"oldPrice"`
 					Images   struct {
 						Default string `json:"default"`
 					} `json:"images"`
@@ -151,8 +176,23 @@ type (
 	}
 )
 
-func (s *Scraper) Scrape(gtin string) ([]core.Product, error) {
-	productData, err := s.fetchProductData(gtin)
+func init() {
+	core.Register(ID, New)
+}
+
+// New builds a Scraper from registry settings
+func New(settings core.Settings) (core.Scraper, error) {
+	return &Scraper{
+		HttpClient: settings.HttpClient(),
+		BaseURL:    settings.BaseURL,
+		Headers:    settings.MergeHeaders(defaultHeaders),
+		APIKey:     settings.APIKey,
+	}, nil
+}
+
+// Scrape searches the Linx catalog, which accepts both barcodes and free-text terms
+func (s *Scraper) Scrape(ctx context.Context, query string) ([]core.Product, error) {
+	productData, err := s.fetchProductData(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -161,21 +201,64 @@ func (s *Scraper) Scrape(gtin string) ([]core.Product, error) {
 		return nil, core.ErrProductNotFound
 	}
 
+	observedAt := time.Now()
 	products := []core.Product{}
 	for _, productData := range productData.Products {
+		if productData.Name == "" {
+			core.LoggerFrom(ctx).Warn("skipping product without a name", "product_id", productData.Id)
+			continue
+		}
 		product := core.Product{
-			Name: productData.Name,
-			GTIN: productData.Id,
-			URL:  url(gtin),
+			Name:         productData.Name,
+			GTIN:         productData.Id,
+			URL:          s.productURL(productData.Url, query),
+			Brand:        productData.Brand,
+			Price:        productData.Price,
+			ListPrice:    productData.OldPrice,
+			Currency:     currency,
+			Availability: availability(productData.Status),
+		}
+		if productData.Images.Default != "" {
+			product.Images = []string{absolute(productData.Images.Default)}
+		}
+		for _, category := range productData.Categories {
+			product.Categories = append(product.Categories, category.Name)
+		}
+		for _, sku := range productData.Skus {
+			quantity := quantity(sku.Properties.Details.Peso, sku.Properties.Details.Embalagem)
+			if product.Quantity == "" {
+				product.Quantity = quantity
+			}
+			unitPrice, unit := core.UnitPrice(sku.Properties.Price, quantity)
+			product.Offers = append(product.Offers, core.Offer{
+				Price:        sku.Properties.Price,
+				ListPrice:    sku.Properties.OldPrice,
+				Currency:     currency,
+				UnitPrice:    unitPrice,
+				Unit:         unit,
+				Availability: availability(sku.Properties.Status),
+				ObservedAt:   observedAt,
+			})
 		}
 		products = append(products, product)
 	}
 
+	if len(products) == 0 && len(productData.Products) > 0 {
+		return nil, fmt.Errorf("%w: no product has a name", core.ErrSchemaChanged)
+	}
 	return products, nil
 }
 
 func (s *Scraper) Info() core.Website {
-	return core.Website{URL: URL}
+	return core.Website{
+		ID:          ID,
+		Name:        Name,
+		URL:         URL,
+		Country:     "BR",
+		Vertical:    core.VerticalFoodservice,
+		QueryTypes:  []core.QueryType{core.QueryGTIN, core.QueryText},
+		GTINFormats: []gtin.Format{gtin.EAN13, gtin.GTIN14},
+	}
 }
 
 // func sanitize(name string) string {
@@ -185,23 +268,69 @@ func (s *Scraper) Info() core.Website {
 // 	return name
 // }
 
-func (s *Scraper) fetchProductData(gtin string) (*ProductData, error) {
+// availability maps the Linx product status
+func availability(status string) core.Availability {
+	switch strings.ToUpper(status) {
+	case "AVAILABLE":
+		return core.AvailabilityInStock
+	case "UNAVAILABLE":
+		return core.AvailabilityOutOfStock
+	default:
+		return core.AvailabilityUnknown
+	}
+}
 
-	s.HttpClient.SetHeaders(map[string]string{
-		"authority":  "api.linximpulse.com",
-		"origin":     "https://www.comprafoodservice.com.br",
-		"referer":    "https://www.comprafoodservice.com.br/buscar/",
-		"user-agent": "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0.0.0 Safari/537.36",
-	})
+// quantity prefers the SKU weight and falls back to its packaging
+func quantity(weight, packaging string) string {
+	if weight != "" {
+		return weight
+	}
+	return packaging
+}
+
+// absolute completes the protocol-relative links Linx returns for images
+func absolute(link string) string {
+	if strings.HasPrefix(link, "//") {
+		return "https:" + link
+	}
+	return link
+}
+
+func (s *Scraper) headers() map[string]string {
+	if s.Headers == nil {
+		return defaultHeaders
+	}
+	return s.Headers
+}
 
+func (s *Scraper) fetchProductData(ctx context.Context, query string) (*ProductData, error) {
 	var productData ProductData
-	err := s.HttpClient.GetJSON(url(gtin), &productData)
+	err := s.HttpClient.GetJSON(ctx, s.url(query), &productData, httpclient.Headers(s.headers()))
 	if err != nil {
 		return nil, err
 	}
 	return &productData, nil
 }
 
-func url(product string) string {
-	return fmt.Sprintf("%s/engage/search/v3/search?apiKey=cfs-new&page=1&resultsPerPage=40&terms=%s&sortBy=relevance&salesChannel=default", URL, product)
+// productURL prefers the store page of the product over the search URL
+func (s *Scraper) productURL(link, query string) string {
+	if link == "" {
+		return s.url(query)
+	}
+	return absolute(link)
+}
+
+func (s *Scraper) url(terms string) string {
+	key := apiKey
+	if s.APIKey != "" {
+		key = s.APIKey
+	}
+	return fmt.Sprintf("%s/engage/search/v3/search?apiKey=%s&page=1&resultsPerPage=40&terms=%s&sortBy=relevance&salesChannel=default", s.baseURL(), neturl.QueryEscape(key), neturl.QueryEscape(terms))
+}
+
+func (s *Scraper) baseURL() string {
+	if s.BaseURL != "" {
+		return s.BaseURL
+	}
+	return URL
 }
@@ -0,0 +1,47 @@
+package comprafoodservicecombr_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	core "achapromo.com/productscout"
+	comprafoodservicecombr "achapromo.com/productscout/websites/comprafoodservice.com.br"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const defaultUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0.0.0 Safari/537.36"
+
+func TestNew_Headers(t *testing.T) {
+	tests := []struct {
+		name      string
+		settings  core.Settings
+		userAgent string
+	}{
+		{"defaults", core.Settings{}, defaultUserAgent},
+		{"user agent setting", core.Settings{UserAgent: "productscout/1.0"}, "productscout/1.0"},
+		{"configured header", core.Settings{Headers: map[string]string{"User-Agent": "productscout-test"}}, "productscout-test"},
+		{"configured header in lowercase", core.Settings{Headers: map[string]string{"user-agent": "productscout-test"}}, "productscout-test"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "https://www.comprafoodservice.com.br", r.Header.Get("Origin"))
+				assert.Equal(t, tt.userAgent, r.Header.Get("User-Agent"))
+				w.Write([]byte(`{"products": []}`))
+			}))
+			defer server.Close()
+			tt.settings.BaseURL = server.URL
+			scraper, err := comprafoodservicecombr.New(tt.settings)
+			assert.NoError(t, err)
+
+			for i := 0; i < 20; i++ { // Headers are maps, so a race shows up as flakiness
+				_, err = scraper.Scrape(context.Background(), "arroz")
+				assert.ErrorIs(t, err, core.ErrProductNotFound)
+			}
+		})
+	}
+}
@@ -1,19 +1,30 @@
 package openfoodfactsorg
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	core "achapromo.com/productscout"
+	"achapromo.com/productscout/gtin"
 	"achapromo.com/productscout/httpclient"
 )
 
 const (
-	URL = "https://world.openfoodfacts.org"
+	ID   = "openfoodfacts.org"
+	Name = "Open Food Facts"
+	URL  = "https://world.openfoodfacts.org"
 )
 
 type (
 	Scraper struct {
 		HttpClient *httpclient.HttpClient
+
+		// BaseURL overrides URL, e.g. to point at a mirror or a test server
+		BaseURL string
+		// Headers are sent with every request, overriding the defaults
+		Headers map[string]string
 	}
 
 	Data struct {
@@ -23,15 +34,32 @@ type (
 	}
 
 	Product struct {
-		NamePt       string `json:"product_name_pt"`
-		Quantity     string `json:"product_quantity"`
-		QuantityUnit string `json:"product_quantity_unit"`
-		Packaging    string `json:"packaging"`
+		NamePt        string `json:"product_name_pt"`
+		Quantity      string `json:"product_quantity"`
+		QuantityUnit  string `json:"product_quantity_unit"`
+		QuantityLabel string `json:"quantity"`
+		Packaging     string `json:"packaging"`
+		Brands        string `json:"brands"`
+		Categories    string `json:"categories"`
+		ImageURL      string `json:"image_url"`
 	}
 )
 
-func (s *Scraper) Scrape(gtin string) ([]core.Product, error) {
-	data, err := s.fetchProductData(gtin)
+func init() {
+	core.Register(ID, New)
+}
+
+// New builds a Scraper from registry settings
+func New(settings core.Settings) (core.Scraper, error) {
+	return &Scraper{
+		HttpClient: settings.HttpClient(),
+		BaseURL:    settings.BaseURL,
+		Headers:    settings.MergeHeaders(nil),
+	}, nil
+}
+
+func (s *Scraper) Scrape(ctx context.Context, query string) ([]core.Product, error) {
+	data, err := s.fetchProductData(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -39,6 +67,9 @@ func (s *Scraper) Scrape(gtin string) ([]core.Product, error) {
 	if data.Status == 0 {
 		return nil, core.ErrProductNotFound
 	}
+	if data.Code == "" {
+		return nil, fmt.Errorf("%w: answer without a code", core.ErrSchemaChanged)
+	}
 
 	product := core.Product{
 		Name: concat(
@@ -47,30 +78,68 @@ func (s *Scraper) Scrape(gtin string) ([]core.Product, error) {
 			data.Product.Quantity,
 			data.Product.QuantityUnit,
 		),
-		GTIN: data.Code,
-		URL:  url(gtin),
+		GTIN:       data.Code,
+		URL:        s.url(query),
+		Brand:      first(split(data.Product.Brands)),
+		Quantity:   data.Product.QuantityLabel,
+		Categories: split(data.Product.Categories),
+	}
+	if data.Product.ImageURL != "" {
+		product.Images = []string{data.Product.ImageURL}
 	}
 
 	return []core.Product{product}, nil
 }
 
 func (s *Scraper) Info() core.Website {
-	return core.Website{URL: URL}
+	return core.Website{
+		ID:          ID,
+		Name:        Name,
+		URL:         URL,
+		Vertical:    core.VerticalOpenData,
+		GTINFormats: []gtin.Format{gtin.EAN13, gtin.UPCA},
+		RateLimit:   core.RateLimit{Requests: 100, Per: time.Minute},
+	}
 }
 
 func concat(name, packaging, quantity, unit string) string {
 	return fmt.Sprintf("%s %s %s%s", name, packaging, quantity, unit)
 }
 
-func (s *Scraper) fetchProductData(gtin string) (*Data, error) {
+// split breaks the comma separated lists Open Food Facts uses for tags
+func split(list string) []string {
+	values := []string{}
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (s *Scraper) fetchProductData(ctx context.Context, query string) (*Data, error) {
 	var productData Data
-	err := s.HttpClient.GetJSON(url(gtin), &productData)
+	err := s.HttpClient.GetJSON(ctx, s.url(query), &productData, httpclient.Headers(s.Headers))
 	if err != nil {
 		return nil, err
 	}
 	return &productData, nil
 }
 
-func url(gtin string) string {
-	return fmt.Sprintf("%s/api/v0/product/%s.json", URL, gtin)
+func (s *Scraper) url(query string) string {
+	return fmt.Sprintf("%s/api/v0/product/%s.json", s.baseURL(), query)
+}
+
+func (s *Scraper) baseURL() string {
+	if s.BaseURL != "" {
+		return s.BaseURL
+	}
+	return URL
 }
@@ -1,6 +1,7 @@
 package openfoodfactsorg_test
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -20,7 +21,7 @@ func TestScraper_ScrapeProductData_Valid(t *testing.T) {
 	scraper := openfoodfactsorg.Scraper{HttpClient: httpclient.NewHttpClient()}
 
 	// Call
-	productData, err := scraper.Scrape("7898215151784")
+	productData, err := scraper.Scrape(context.Background(), "7898215151784")
 
 	// Verify
 	assert.NoError(t, err)
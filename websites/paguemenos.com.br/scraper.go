@@ -1,19 +1,49 @@
 package paguemenoscombr
 
 import (
+	"context"
 	"fmt"
+	neturl "net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	core "achapromo.com/productscout"
+	"achapromo.com/productscout/gtin"
 	"achapromo.com/productscout/httpclient"
 )
 
 const (
-	URL = "https://prod.apipmenos.com"
+	ID   = "paguemenos.com.br"
+	Name = "Pague Menos"
+	URL  = "https://prod.apipmenos.com"
+
+	currency = "BRL"
+	apiKey   = "Yt1tDH9WNx5pmTXrBPBFH8mHAMJ5Gbb3dbSdu12d"
 )
 
+var defaultHeaders = map[string]string{
+	"sec-ch-ua":          `"Chromium";v="122", "Not(A:Brand";v="24", "Google Chrome";v="122"`,
+	"Accept":             "application/json, text/plain, */*",
+	"Referer":            "https://www.paguemenos.com.br/",
+	"sec-ch-ua-mobile":   "?0",
+	"User-Agent":         "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0.0.0 Safari/537.36",
+	"x-api-key":          apiKey,
+	"sec-ch-ua-platform": "macOS",
+}
+
 type (
 	Scraper struct {
 		HttpClient *httpclient.HttpClient
+
+		// BaseURL overrides URL, e.g. to point at a mirror or a test server
+		BaseURL string
+		// Headers are sent with every request; New fills them with the
+		// defaults overridden by the configured ones, and nil sends the defaults
+		Headers map[string]string
+		// APIKey overrides the x-api-key header of the store API
+		APIKey string
 	}
 
 	Result struct {
@@ -96,10 +126,12 @@ type (
 			Description      string      `json:"description"`
 			IsProdutoDigital bool        `json:"isProdutoDigital"`
 			Items            []struct {
-				SKU         int    `json:"sku"`
-				ItemID      int    `json:"itemId"`
-				EAN         string `json:"ean"`
-				ReferenceID []struct {
+				SKU             int     `json:"sku"`
+				ItemID          int     `json:"itemId"`
+				EAN             string  `json:"ean"`
+				MeasurementUnit string  `json:"measurementUnit"`
+				UnitMultiplier  float64 `json:"unitMultiplier"`
+				ReferenceID     []struct {
 					Key   string `json:"key"`
 					Value string `json:"value"`
 				} `json:"referenceId"`
@@ -121,21 +153,12 @@ type (
 						DiscountHighLightApp        bool          `json:"discountHighLightApp"`
 						DiscountHighLight           []interface{} `json:"discountHighLight"`
 						GiftSKUIds                  []interface{} `json:"giftSkuIds"`
-						Teasers                     []struct {
-							NameKBackingField       string `json:"nameKBackingField"`
-							Value                   string `json:"value"`
-							ConditionsKBackingField struct {
-								MinimumQuantityKBackingField string `json:"minimumQuantityKBackingField"`
-								Value                        string `json:"value"`
-							} `json:"conditionsKBackingField"`
-							Conditions struct {
-								MinimumQuantityKBackingField string `json:"minimumQuantityKBackingField"`
-								Value                        string `json:"value"`
-							} `json:"conditions"`
-						} `json:"teasers"`
-						BuyTogether            []interface{} `json:"buyTogether"`
-						ItemMetadataAttachment []interface{} `json:"itemMetadataAttachment"`
-						GetInfoErrorMessage    interface{}   `json:"getInfoErrorMessage"`
+						Teasers                     []Teaser      `json:"teasers"`
+						BuyTogether                 []interface{} `json:"buyTogether"`
+						ItemMetadataAttachment      []interface{} `json:"itemMetadataAttachment"`
+						GetInfoErrorMessage         interface{}   `json:"getInfoErrorMessage"`
+						AvailableQuantity           *int          `json:"availableQuantity"`
+						IsAvailable                 *bool         `json:"isAvailable"`
 					} `json:"commertialOffer"`
 				} `json:"sellers"`
 			} `json:"items"`
@@ -163,10 +186,38 @@ type (
 			Cashback              []string      `json:"cashback"`
 		} `json:"data"`
 	}
+
+	Teaser struct {
+		NameKBackingField       string `json:"nameKBackingField"`
+		Value                   string `json:"value"`
+		ConditionsKBackingField struct {
+			MinimumQuantityKBackingField string `json:"minimumQuantityKBackingField"`
+			Value                        string `json:"value"`
+		} `json:"conditionsKBackingField"`
+		Conditions struct {
+			MinimumQuantityKBackingField string `json:"minimumQuantityKBackingField"`
+			Value                        string `json:"value"`
+		} `json:"conditions"`
+	}
 )
 
-func (s *Scraper) Scrape(gtin string) ([]core.Product, error) {
-	res, err := s.fetchProductData(gtin)
+func init() {
+	core.Register(ID, New)
+}
+
+// New builds a Scraper from registry settings
+func New(settings core.Settings) (core.Scraper, error) {
+	return &Scraper{
+		HttpClient: settings.HttpClient(),
+		BaseURL:    settings.BaseURL,
+		Headers:    settings.MergeHeaders(defaultHeaders),
+		APIKey:     settings.APIKey,
+	}, nil
+}
+
+// Scrape searches the Linx catalog, which accepts both barcodes and free-text terms
+func (s *Scraper) Scrape(ctx context.Context, query string) ([]core.Product, error) {
+	res, err := s.fetchProductData(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -175,47 +226,193 @@ func (s *Scraper) Scrape(gtin string) ([]core.Product, error) {
 		return nil, core.ErrProductNotFound
 	}
 
+	observedAt := time.Now()
 	products := []core.Product{}
 	for _, product := range res.Data {
+		if product.ProductName == "" {
+			core.LoggerFrom(ctx).Warn("skipping product without a name", "product_id", product.ProductID)
+			continue
+		}
 
-		gtin := ""
+		ean, quantity := "", ""
+		var images []string
+		var offers []core.Offer
+		var price, listPrice float64
 		if len(product.Items) > 0 {
-			gtin = product.Items[0].EAN
+			item := product.Items[0]
+			ean = item.EAN
+			quantity = measure(item.UnitMultiplier, item.MeasurementUnit)
+			for _, image := range item.Images {
+				images = append(images, image.ImageURL)
+			}
+			for _, seller := range item.Sellers {
+				offer := seller.CommertialOffer
+				unitPrice, unit := core.UnitPrice(offer.Price, quantity)
+				offers = append(offers, core.Offer{
+					Seller:       seller.SellerName,
+					Price:        offer.Price,
+					ListPrice:    offer.ListPrice,
+					Currency:     currency,
+					UnitPrice:    unitPrice,
+					Unit:         unit,
+					Availability: availability(offer.IsAvailable, offer.AvailableQuantity),
+					Promo:        promo(offer.DiscountLabel, offer.Teasers),
+					ObservedAt:   observedAt,
+				})
+			}
+			if i := listed(offers); i >= 0 {
+				price, listPrice = offers[i].Price, offers[i].ListPrice
+			}
 		}
 		products = append(products, core.Product{
-			Name: product.ProductName,
-			GTIN: gtin,
-			URL:  url(gtin),
+			Name:         product.ProductName,
+			GTIN:         ean,
+			URL:          s.url(ean),
+			Quantity:     quantity,
+			Brand:        product.Brand,
+			Images:       images,
+			Categories:   categories(product.Categories),
+			Price:        price,
+			ListPrice:    listPrice,
+			Currency:     currency,
+			Availability: stock(offers),
+			Offers:       offers,
 		})
 	}
 
+	if len(products) == 0 && len(res.Data) > 0 {
+		return nil, fmt.Errorf("%w: no product has a name", core.ErrSchemaChanged)
+	}
 	return products, nil
 }
 
 func (s *Scraper) Info() core.Website {
-	return core.Website{URL: URL}
+	return core.Website{
+		ID:          ID,
+		Name:        Name,
+		URL:         URL,
+		Country:     "BR",
+		Vertical:    core.VerticalPharmacy,
+		QueryTypes:  []core.QueryType{core.QueryGTIN, core.QueryText},
+		GTINFormats: []gtin.Format{gtin.EAN13},
+	}
+}
+
+// categories turns VTEX category paths like "/Medicamentos/Analgesicos/" into
+// their last segment, skipping duplicates
+func categories(paths []string) []string {
+	names := []string{}
+	for _, path := range paths {
+		segments := strings.Split(strings.Trim(path, "/"), "/")
+		if name := segments[len(segments)-1]; name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// availability reads the stock of a seller offer, which VTEX gives as a flag,
+// an available quantity or both
+func availability(isAvailable *bool, quantity *int) core.Availability {
+	switch {
+	case isAvailable != nil && !*isAvailable, quantity != nil && *quantity <= 0:
+		return core.AvailabilityOutOfStock
+	case isAvailable != nil, quantity != nil:
+		return core.AvailabilityInStock
+	default:
+		return core.AvailabilityUnknown
+	}
 }
 
-func (s *Scraper) fetchProductData(gtin string) (*Result, error) {
+// listed returns the index of the offer shown as the product price: the first
+// one in stock, else the first one. It returns -1 without offers.
+func listed(offers []core.Offer) int {
+	for i, offer := range offers {
+		if offer.Availability != core.AvailabilityOutOfStock {
+			return i
+		}
+	}
+	if len(offers) > 0 {
+		return 0
+	}
+	return -1
+}
+
+// stock sums up the availability of the offers: in stock when any seller has
+// it, out of stock when every seller lacks it
+func stock(offers []core.Offer) core.Availability {
+	out := 0
+	for _, offer := range offers {
+		switch offer.Availability {
+		case core.AvailabilityInStock:
+			return core.AvailabilityInStock
+		case core.AvailabilityOutOfStock:
+			out++
+		}
+	}
+	if out > 0 && out == len(offers) {
+		return core.AvailabilityOutOfStock
+	}
+	return core.AvailabilityUnknown
+}
+
+// measure turns the VTEX measurement unit of an item into a quantity like
+// "1 un" or "0.5 kg"
+func measure(multiplier float64, unit string) string {
+	if unit == "" {
+		return ""
+	}
+	if multiplier <= 0 {
+		multiplier = 1
+	}
+	return strconv.FormatFloat(multiplier, 'f', -1, 64) + " " + unit
+}
+
+// promo describes the discount of an offer, joining its label and teasers
+func promo(label string, teasers []Teaser) string {
+	parts := []string{}
+	if label != "" {
+		parts = append(parts, label)
+	}
+	for _, teaser := range teasers {
+		if teaser.NameKBackingField != "" {
+			parts = append(parts, teaser.NameKBackingField)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (s *Scraper) headers() map[string]string {
+	if s.Headers == nil {
+		return defaultHeaders
+	}
+	return s.Headers
+}
+
+func (s *Scraper) fetchProductData(ctx context.Context, query string) (*Result, error) {
 	var productData Result
 
-	s.HttpClient.SetHeaders(map[string]string{
-		"sec-ch-ua":          `"Chromium";v="122", "Not(A:Brand";v="24", "Google Chrome";v="122"`,
-		"Accept":             "application/json, text/plain, */*",
-		"Referer":            "https://www.paguemenos.com.br/",
-		"sec-ch-ua-mobile":   "?0",
-		"User-Agent":         "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0.0.0 Safari/537.36",
-		"x-api-key":          "Yt1tDH9WNx5pmTXrBPBFH8mHAMJ5Gbb3dbSdu12d",
-		"sec-ch-ua-platform": "macOS",
-	})
-
-	err := s.HttpClient.GetJSON(url(gtin), &productData)
+	opts := []httpclient.RequestOption{httpclient.Headers(s.headers())}
+	if s.APIKey != "" {
+		opts = append(opts, httpclient.Headers(map[string]string{"x-api-key": s.APIKey}))
+	}
+
+	err := s.HttpClient.GetJSON(ctx, s.url(query), &productData, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return &productData, nil
 }
 
-func url(product string) string {
-	return fmt.Sprintf("%s/buscacatalogo/api/searchurl?salesChannel=1&company=1&url=%%2Fengage%%2Fsearch%%2Fv3%%2Fsearch%%3Fapikey%%3Dfarmacia-paguemenos%%26terms%%3D%s%%26page%%3D1%%26resultsperpage%%3D48%%26showonlyavailable%%3Dfalse%%26allowredirect%%3Dtrue&deviceId=47298a9e-9593-4895-87bf-e05ac4c3b24f&source=desktop", URL, product)
+// url builds the proxy URL; terms are escaped twice since they go inside the
+// already escaped url parameter
+func (s *Scraper) url(terms string) string {
+	return fmt.Sprintf("%s/buscacatalogo/api/searchurl?salesChannel=1&company=1&url=%%2Fengage%%2Fsearch%%2Fv3%%2Fsearch%%3Fapikey%%3Dfarmacia-paguemenos%%26terms%%3D%s%%26page%%3D1%%26resultsperpage%%3D48%%26showonlyavailable%%3Dfalse%%26allowredirect%%3Dtrue&deviceId=47298a9e-9593-4895-87bf-e05ac4c3b24f&source=desktop", s.baseURL(), neturl.QueryEscape(neturl.QueryEscape(terms)))
+}
+
+func (s *Scraper) baseURL() string {
+	if s.BaseURL != "" {
+		return s.BaseURL
+	}
+	return URL
 }
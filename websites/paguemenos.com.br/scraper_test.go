@@ -0,0 +1,116 @@
+package paguemenoscombr_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	core "achapromo.com/productscout"
+	"achapromo.com/productscout/httpclient"
+	paguemenoscombr "achapromo.com/productscout/websites/paguemenos.com.br"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const defaultUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0.0.0 Safari/537.36"
+
+func createServer(t *testing.T, response string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/buscacatalogo/api/searchurl", r.URL.Path)
+		w.Write([]byte(response))
+	}))
+}
+
+func TestScraper_Scrape_Offers(t *testing.T) {
+	server := createServer(t, `{"data": [{
+		"productName": "Dipirona 1g 10 Comprimidos",
+		"brand": "EMS",
+		"items": [{
+			"ean": "7896004703398",
+			"measurementUnit": "un",
+			"unitMultiplier": 10,
+			"sellers": [
+				{"sellerName": "Pague Menos", "commertialOffer": {"price": 8.99, "listPrice": 9.99, "isAvailable": false, "availableQuantity": 0}},
+				{"sellerName": "Parceiro", "commertialOffer": {"price": 12.50, "listPrice": 12.50, "isAvailable": true, "availableQuantity": 7}}
+			]
+		}]
+	}]}`)
+	defer server.Close()
+	scraper := paguemenoscombr.Scraper{HttpClient: httpclient.NewHttpClient(), BaseURL: server.URL}
+
+	products, err := scraper.Scrape(context.Background(), "7896004703398")
+
+	assert.NoError(t, err)
+	if !assert.Len(t, products, 1) || !assert.Len(t, products[0].Offers, 2) {
+		return
+	}
+	assert.Equal(t, "10 un", products[0].Quantity)
+	assert.Equal(t, core.AvailabilityOutOfStock, products[0].Offers[0].Availability)
+	assert.Equal(t, core.AvailabilityInStock, products[0].Offers[1].Availability)
+	assert.Equal(t, 1.25, products[0].Offers[1].UnitPrice)
+	assert.Equal(t, "un", products[0].Offers[1].Unit)
+	assert.Equal(t, core.AvailabilityInStock, products[0].Availability)
+	assert.Equal(t, 12.50, products[0].Price, "the listed price comes from a seller with stock")
+
+	cheapest := core.CheapestOffers(products)["7896004703398"]
+	assert.Equal(t, "Parceiro", cheapest.Seller, "the out-of-stock seller cannot win")
+}
+
+func TestScraper_Scrape_SkipsProductsWithoutName(t *testing.T) {
+	server := createServer(t, `{"data": [
+		{"productName": "", "items": [{"ean": "7891000000001"}]},
+		{"productName": "Paracetamol 750mg", "items": [{"ean": "7891000000002"}]}
+	]}`)
+	defer server.Close()
+	scraper := paguemenoscombr.Scraper{HttpClient: httpclient.NewHttpClient(), BaseURL: server.URL}
+
+	products, err := scraper.Scrape(context.Background(), "paracetamol")
+
+	assert.NoError(t, err)
+	if assert.Len(t, products, 1) {
+		assert.Equal(t, "Paracetamol 750mg", products[0].Name)
+	}
+}
+
+func TestScraper_Scrape_OnlyProductsWithoutName(t *testing.T) {
+	server := createServer(t, `{"data": [{"productName": "", "items": []}]}`)
+	defer server.Close()
+	scraper := paguemenoscombr.Scraper{HttpClient: httpclient.NewHttpClient(), BaseURL: server.URL}
+
+	_, err := scraper.Scrape(context.Background(), "paracetamol")
+
+	assert.ErrorIs(t, err, core.ErrSchemaChanged)
+}
+
+func TestNew_Headers(t *testing.T) {
+	tests := []struct {
+		name      string
+		settings  core.Settings
+		userAgent string
+	}{
+		{"defaults", core.Settings{}, defaultUserAgent},
+		{"user agent setting", core.Settings{UserAgent: "productscout/1.0"}, "productscout/1.0"},
+		{"configured header", core.Settings{Headers: map[string]string{"User-Agent": "productscout-test"}}, "productscout-test"},
+		{"configured header in lowercase", core.Settings{Headers: map[string]string{"user-agent": "productscout-test"}}, "productscout-test"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "https://www.paguemenos.com.br/", r.Header.Get("Referer"))
+				assert.Equal(t, tt.userAgent, r.Header.Get("User-Agent"))
+				w.Write([]byte(`{"data": []}`))
+			}))
+			defer server.Close()
+			tt.settings.BaseURL = server.URL
+			scraper, err := paguemenoscombr.New(tt.settings)
+			assert.NoError(t, err)
+
+			for i := 0; i < 20; i++ { // Headers are maps, so a race shows up as flakiness
+				_, err = scraper.Scrape(context.Background(), "dipirona")
+				assert.ErrorIs(t, err, core.ErrProductNotFound)
+			}
+		})
+	}
+}